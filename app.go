@@ -26,18 +26,20 @@ type App struct {
 	ctx context.Context
 	mtx sync.Mutex
 
-	carclasses     car.CarClasses         // Car classes and car membership for this series
-	pastResults    []results.Result       // Previous weeks results for this season from the iRacing API
-	telemetryData  telemetry.Data         // Shared memory updated every `refreshSeconds`
-	prediction     *predictor.Predictor   // Predict standing using past results and current telemetry
-	irAPI          iracing.IracingService // iRacing API
-	pointsPerSplit points.PointsPerSplit  // Points structure
-	refreshSeconds int                    // How often to read telemetry
-	countBestOf    int                    // Count best of n races in season
-	seriesID       int                    // iRacing series ID
-	seasonYear     int                    // E.g. 2024, 2025
-	seasonQuarter  int                    // E.g. 1,2,3
-	showTopN       int                    // Display top n standings
+	carclasses     car.CarClasses           // Car classes and car membership for this series
+	pastResults    []results.Result         // Previous weeks results for this season from the iRacing API
+	telemetryData  telemetry.Data           // Shared memory updated every `refreshSeconds`
+	prediction     *predictor.Predictor     // Predict standing using past results and current telemetry
+	irAPI          iracing.IracingService   // iRacing API
+	pointsPerSplit points.PointsPerSplit    // Points structure
+	refreshSeconds int                      // How often to read telemetry
+	countBestOf    int                      // Count best of n races in season
+	seriesID       int                      // iRacing series ID
+	seasonYear     int                      // E.g. 2024, 2025
+	seasonQuarter  int                      // E.g. 1,2,3
+	showTopN       int                      // Display top n standings
+	positionSource predictor.PositionSource // Class positions from the start/finish line or interpolated
+	creditRule     predictor.CreditRule     // Who scores a car's result in team events
 }
 
 type Config struct {
@@ -181,6 +183,8 @@ func (a *App) LatestStandings() live.PredictedStandings {
 
 	if a.prediction == nil {
 		a.prediction = predictor.NewPredictor(a.pointsPerSplit, a.countBestOf, a.carclasses)
+		a.prediction.SetPositionSource(a.positionSource)
+		a.prediction.SetCreditRule(a.creditRule)
 	}
 
 	data := a.telemetryData.Telemetry()
@@ -25,7 +25,7 @@ export const Standings = (props: Props) => {
                     <div className='container py-2'>
                         {header(0)}
                         {dummyRows(10)}
-                        {footer(null, 0, "", 0, "Waiting for iRacing", 0, 0)}
+                        {footer(null, 0, "", 0, "Waiting for iRacing", 0, 0, "", "")}
                     </div>
                 </div>
         </div>
@@ -75,7 +75,12 @@ export const Standings = (props: Props) => {
                         {car_number}
                     </div>
                 </div>
-                <div className="col-4 p-0">{row.driver_name}</div>
+                <div className="col-4 p-0">
+                    {row.driver_name}
+                    {row.pitting && <span className="irc-pitting ms-1">PIT</span>}
+                    {row.incidents > 0 && <span className={"ms-1 " + (row.near_incident_limit ? "irc-incidents-limit" : "irc-incidents")}
+                        title={row.incident_deltas?.map((d) => `+${d.count}x on lap ${d.lap}`).join("\n")}>{row.incidents}x</span>}
+                </div>
                 <div className="col-3 p-0 text-start">{row.car_names}</div>
                 <div className="col-1 p-0">{row.current_position}</div>
                 <div className="col-1 p-0 text-end">{row.predicted_points}</div>
@@ -93,7 +98,9 @@ export const Standings = (props: Props) => {
     if (rows.length !== 0) {
         const h = header(carClassID);
         const f = footer(dispatch, carClassID, standings.standings[carClassID].car_class_name, standings.standings[carClassID].sof_by_car_class,
-            standings.track_name, standings.count_best_of, standings.standings[carClassID].class_leader_laps_complete);
+            standings.track_name, standings.count_best_of, standings.standings[carClassID].class_leader_laps_complete,
+            standings.final_lap ? "Final lap: provisional points" : standings.result_from_session ? (standings.provisional ? "Provisional" : "Official") : "",
+            standings.flag);
 
         const table = [
             <div key={carClassID} id={`car-class-id-${carClassID}`} className="irc-standings small">
@@ -126,7 +133,7 @@ const header = (carClassID: number) => {
     )
 }
 
-const footer = (dispatch: any, carClassID: number, carClassName: string, sof: number, trackName: string, bestOf: number, lapsComplete: number) => {
+const footer = (dispatch: any, carClassID: number, carClassName: string, sof: number, trackName: string, bestOf: number, lapsComplete: number, result: string, flag: string) => {
     return (
         <div key={`footer-${carClassID}`} className="row irc-footer text-center pt-2">
             <div className="col-2 ps-0 text-center">
@@ -136,7 +143,7 @@ const footer = (dispatch: any, carClassID: number, carClassName: string, sof: nu
                 </div>
             </div>
             <div className="col-2 p-0 text-start">SOF :{sof}</div>
-            <div className="col p-0 text-start">{trackName}</div>
+            <div className="col p-0 text-start">{trackName}{result && <span className={`ms-1 irc-result irc-flag-${flag}`}>{result}</span>}</div>
             <div className="col-1 p-0 text-end">Best: {bestOf}</div>
             <div className="col-2 p-0 text-end">Laps: {lapsComplete}</div>
         </div>
@@ -1,5 +1,10 @@
 export namespace live {
 	
+	export interface IncidentDelta {
+	    lap: number;
+	    count: number;
+	    total: number;
+	}
 	export interface PredictedStanding {
 	    driving: boolean;
 	    cust_id: number;
@@ -11,6 +16,16 @@ export namespace live {
 	    predicted_points: number;
 	    change: number;
 	    car_names: string[];
+	    last_lap_time: number;
+	    best_lap_time: number;
+	    average_lap_time: number;
+	    gap_to_class_leader: number;
+	    interval_to_car_ahead: number;
+	    dnf: boolean;
+	    pitting: boolean;
+	    incidents: number;
+	    incident_deltas: IncidentDelta[];
+	    near_incident_limit: boolean;
 	}
 	export interface Standing {
 	    sof_by_car_class: number;
@@ -25,6 +40,14 @@ export namespace live {
 	    count_best_of: number;
 	    self_car_class_id: number;
 	    car_class_ids: number[];
+	    incident_limit: number;
+	    result_from_session: boolean;
+	    provisional: boolean;
+	    session_state: string;
+	    flag: string;
+	    time_remaining: number;
+	    laps_remaining: number;
+	    final_lap: boolean;
 	    standings: {[key: number]: Standing};
 	}
 
@@ -0,0 +1,230 @@
+package irsdk
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ianhaycox/ir-standings/irsdk/events"
+)
+
+// BroadcastFunc sends a broadcast message to iRacing, events.BroadcastMsg unless injected for tests
+type BroadcastFunc func(msgName string, msg int, p1 int, p2 interface{}, p3 int) bool
+
+// Broadcaster controls the camera, replay, chat, pit service and telemetry recording with validated commands
+type Broadcaster struct {
+	send BroadcastFunc
+}
+
+// NewBroadcaster sending with send, or events.BroadcastMsg if nil
+func NewBroadcaster(send BroadcastFunc) *Broadcaster {
+	if send == nil {
+		send = events.BroadcastMsg
+	}
+
+	return &Broadcaster{send: send}
+}
+
+const (
+	maxCars        = 64 // IRSDK_MAX_CARS
+	maxChatMacro   = 15
+	maxReplaySpeed = 16
+	maxCarNumber   = 999
+)
+
+// FocusOnCar switches the camera to the car number as displayed, e.g. "007", in camera group and camera, 0 is unchanged
+func (b *Broadcaster) FocusOnCar(carNumber string, group, camera int) error {
+	num, err := padCarNumber(carNumber)
+	if err != nil {
+		return err
+	}
+
+	return b.camSwitch(BroadcastCamSwitchNum, num, group, camera)
+}
+
+// FocusOnPosition switches the camera to the car in race position, from 1
+func (b *Broadcaster) FocusOnPosition(position, group, camera int) error {
+	if position < 1 {
+		return fmt.Errorf("%w: position %d", ErrInvalidBroadcast, position)
+	}
+
+	return b.camSwitch(BroadcastCamSwitchPos, position, group, camera)
+}
+
+// FocusAt switches the camera to CamFocusAtIncident, CamFocusAtLeader or CamFocusAtExiting
+func (b *Broadcaster) FocusAt(focus, group, camera int) error {
+	if focus < CamFocusAtIncident || focus > CamFocusAtExiting {
+		return fmt.Errorf("%w: focus %d", ErrInvalidBroadcast, focus)
+	}
+
+	return b.camSwitch(BroadcastCamSwitchPos, focus, group, camera)
+}
+
+func (b *Broadcaster) camSwitch(cmd, target, group, camera int) error {
+	if group < 0 || camera < 0 {
+		return fmt.Errorf("%w: camera group %d camera %d", ErrInvalidBroadcast, group, camera)
+	}
+
+	return b.broadcast(cmd, target, group, camera)
+}
+
+// SetCameraState e.g. CameraStateCamToolActive|CameraStateUIHidden
+func (b *Broadcaster) SetCameraState(state CameraState) error {
+	return b.broadcast(BroadcastCamSetState, int(state), 0, 0)
+}
+
+// ReplaySetPlaySpeed from -16 to 16, negative rewinds, 0 pauses, in slow motion the speed is 1/(speed+1)
+func (b *Broadcaster) ReplaySetPlaySpeed(speed int, slowMotion bool) error {
+	if speed < -maxReplaySpeed || speed > maxReplaySpeed {
+		return fmt.Errorf("%w: replay speed %d", ErrInvalidBroadcast, speed)
+	}
+
+	slow := 0
+	if slowMotion {
+		slow = 1
+	}
+
+	return b.broadcast(BroadcastReplaySetPlaySpeed, speed, slow, 0)
+}
+
+// ReplaySetPlayPosition to frame relative to RpyPosBegin, RpyPosCurrent or RpyPosEnd
+func (b *Broadcaster) ReplaySetPlayPosition(mode, frame int) error {
+	if mode < RpyPosBegin || mode >= RpyPosLast {
+		return fmt.Errorf("%w: replay position mode %d", ErrInvalidBroadcast, mode)
+	}
+
+	if frame < math.MinInt32 || frame > math.MaxInt32 {
+		return fmt.Errorf("%w: replay frame %d", ErrInvalidBroadcast, frame)
+	}
+
+	low, high := words(int32(frame))
+
+	return b.broadcast(BroadcastReplaySetPlayPosition, mode, low, high)
+}
+
+// ReplaySearch the tape with one of the RpySrch modes, e.g. RpySrchNextIncident
+func (b *Broadcaster) ReplaySearch(mode int) error {
+	if mode < RpySrchToStart || mode >= RpySrchLast {
+		return fmt.Errorf("%w: replay search mode %d", ErrInvalidBroadcast, mode)
+	}
+
+	return b.broadcast(BroadcastReplaySearch, mode, 0, 0)
+}
+
+// ReplaySearchSessionTime moves the replay to the time into the session
+func (b *Broadcaster) ReplaySearchSessionTime(sessionNum int, sessionTime time.Duration) error {
+	ms := sessionTime.Milliseconds()
+
+	if sessionNum < 0 || ms < 0 || ms > math.MaxInt32 {
+		return fmt.Errorf("%w: session %d time %s", ErrInvalidBroadcast, sessionNum, sessionTime)
+	}
+
+	low, high := words(int32(ms))
+
+	return b.broadcast(BroadcastReplaySearchSessionTime, sessionNum, low, high)
+}
+
+// ReplayEraseTape clears the replay tape
+func (b *Broadcaster) ReplayEraseTape() error {
+	return b.broadcast(BroadcastReplaySetState, RpyStateEraseTape, 0, 0)
+}
+
+// ReloadTextures of all cars
+func (b *Broadcaster) ReloadTextures() error {
+	return b.broadcast(BroadcastReloadTextures, ReloadTexturesAll, 0, 0)
+}
+
+// ReloadCarTextures of one car
+func (b *Broadcaster) ReloadCarTextures(carIdx int) error {
+	if carIdx < 0 || carIdx >= maxCars {
+		return fmt.Errorf("%w: carIdx %d", ErrInvalidBroadcast, carIdx)
+	}
+
+	return b.broadcast(BroadcastReloadTextures, ReloadTexturesCarIdx, carIdx, 0)
+}
+
+// ChatMacro sends chat macro 1 to 15
+func (b *Broadcaster) ChatMacro(macro int) error {
+	if macro < 1 || macro > maxChatMacro {
+		return fmt.Errorf("%w: chat macro %d", ErrInvalidBroadcast, macro)
+	}
+
+	return b.broadcast(BroadcastChatComand, ChatCommandMacro, macro, 0)
+}
+
+// Chat opens, replies in or closes the chat window with ChatCommandBeginChat, ChatCommandReply or ChatCommandCancel
+func (b *Broadcaster) Chat(cmd int) error {
+	if cmd < ChatCommandBeginChat || cmd > ChatCommandCancel {
+		return fmt.Errorf("%w: chat command %d", ErrInvalidBroadcast, cmd)
+	}
+
+	return b.broadcast(BroadcastChatComand, cmd, 0, 0)
+}
+
+// PitCommand one of the PitCommand modes, param is liters of fuel or tire pressure in KPa, 0 for the existing amount
+func (b *Broadcaster) PitCommand(cmd, param int) error {
+	if cmd < PitCommandClear || cmd > PitCommandClearFuel || param < 0 {
+		return fmt.Errorf("%w: pit command %d param %d", ErrInvalidBroadcast, cmd, param)
+	}
+
+	return b.broadcast(BroadcastPitCommand, cmd, param, 0)
+}
+
+// TelemCommand starts, stops or restarts telemetry recording
+func (b *Broadcaster) TelemCommand(cmd int) error {
+	if cmd < TelemCommandStop || cmd > TelemCommandRestart {
+		return fmt.Errorf("%w: telemetry command %d", ErrInvalidBroadcast, cmd)
+	}
+
+	return b.broadcast(BroadcastTelemCommand, cmd, 0, 0)
+}
+
+// FFBMaxForce sets the force feedback maximum force in Nm
+func (b *Broadcaster) FFBMaxForce(nm float32) error {
+	if nm < 0 || float64(nm)*65536 > math.MaxInt32 {
+		return fmt.Errorf("%w: max force %f", ErrInvalidBroadcast, nm)
+	}
+
+	// floats are sent as 16.16 fixed point
+	low, high := words(int32(nm * 65536)) //nolint:mnd // 16.16
+
+	return b.broadcast(BroadcastFFBCommand, FFBCommandMaxForce, low, high)
+}
+
+func (b *Broadcaster) broadcast(cmd, p1, p2, p3 int) error {
+	if !b.send(broadcastMsgName, cmd, p1, p2, p3) {
+		return fmt.Errorf("%w: command %d", ErrBroadcastFailed, cmd)
+	}
+
+	return nil
+}
+
+// words of a 32 bit parameter, sent in p2 and p3 which the message packs with MAKELONG(p2, p3)
+func words(v int32) (int, int) {
+	u := uint32(v)
+
+	return int(u & 0xffff), int(u >> 16) //nolint:mnd // 16 bit words
+}
+
+// padCarNumber encodes leading zeros so "07" and "7" are different cars, irsdk padCarNum
+func padCarNumber(carNumber string) (int, error) {
+	num, err := strconv.Atoi(carNumber)
+	if err != nil || num < 0 || num > maxCarNumber {
+		return 0, fmt.Errorf("%w: car number %q", ErrInvalidBroadcast, carNumber)
+	}
+
+	zeros := len(carNumber) - len(strings.TrimLeft(carNumber, "0"))
+	if zeros == len(carNumber) {
+		zeros-- // "0" and "00" are car 0 with one fewer leading zero
+	}
+
+	if zeros == 0 {
+		return num, nil
+	}
+
+	digits := len(strconv.Itoa(num))
+
+	return num + 1000*(digits+zeros), nil //nolint:mnd // irsdk encoding
+}
@@ -0,0 +1,100 @@
+package irsdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sentMsg struct {
+	msg, p1, p2, p3 int
+}
+
+// recordBroadcasts captures the messages instead of sending them to iRacing
+func recordBroadcasts(ok bool) (*Broadcaster, *[]sentMsg) {
+	sent := make([]sentMsg, 0)
+
+	return NewBroadcaster(func(msgName string, msg int, p1 int, p2 interface{}, p3 int) bool {
+		if msgName == broadcastMsgName {
+			sent = append(sent, sentMsg{msg, p1, p2.(int), p3})
+		}
+
+		return ok
+	}), &sent
+}
+
+func TestBroadcaster(t *testing.T) {
+	b, sent := recordBroadcasts(true)
+
+	tests := []struct {
+		name string
+		send func() error
+		want sentMsg
+	}{
+		{"Focus on car number", func() error { return b.FocusOnCar("12", 3, 1) }, sentMsg{BroadcastCamSwitchNum, 12, 3, 1}},
+		{"Focus on car number with leading zeros", func() error { return b.FocusOnCar("007", 3, 0) }, sentMsg{BroadcastCamSwitchNum, 3007, 3, 0}},
+		{"Focus on car zero", func() error { return b.FocusOnCar("0", 1, 0) }, sentMsg{BroadcastCamSwitchNum, 0, 1, 0}},
+		{"Focus on car double zero", func() error { return b.FocusOnCar("00", 1, 0) }, sentMsg{BroadcastCamSwitchNum, 2000, 1, 0}},
+		{"Focus on position", func() error { return b.FocusOnPosition(2, 1, 0) }, sentMsg{BroadcastCamSwitchPos, 2, 1, 0}},
+		{"Focus on leader", func() error { return b.FocusAt(CamFocusAtLeader, 1, 0) }, sentMsg{BroadcastCamSwitchPos, -2, 1, 0}},
+		{"Camera state", func() error { return b.SetCameraState(CameraStateUIHidden) }, sentMsg{BroadcastCamSetState, 8, 0, 0}},
+		{"Replay speed", func() error { return b.ReplaySetPlaySpeed(-4, true) }, sentMsg{BroadcastReplaySetPlaySpeed, -4, 1, 0}},
+		{"Replay next incident", func() error { return b.ReplaySearch(RpySrchNextIncident) }, sentMsg{BroadcastReplaySearch, RpySrchNextIncident, 0, 0}},
+		{"Replay position frame in two words", func() error { return b.ReplaySetPlayPosition(RpyPosBegin, 0x12345) }, sentMsg{BroadcastReplaySetPlayPosition, RpyPosBegin, 0x2345, 0x1}},
+		{"Replay position negative frame", func() error { return b.ReplaySetPlayPosition(RpyPosCurrent, -1) }, sentMsg{BroadcastReplaySetPlayPosition, RpyPosCurrent, 0xffff, 0xffff}},
+		{"Replay session time in ms", func() error { return b.ReplaySearchSessionTime(2, 90*time.Second) }, sentMsg{BroadcastReplaySearchSessionTime, 2, 90000 & 0xffff, 90000 >> 16}},
+		{"Erase tape", b.ReplayEraseTape, sentMsg{BroadcastReplaySetState, RpyStateEraseTape, 0, 0}},
+		{"Reload car textures", func() error { return b.ReloadCarTextures(5) }, sentMsg{BroadcastReloadTextures, ReloadTexturesCarIdx, 5, 0}},
+		{"Chat macro", func() error { return b.ChatMacro(15) }, sentMsg{BroadcastChatComand, ChatCommandMacro, 15, 0}},
+		{"Begin chat", func() error { return b.Chat(ChatCommandBeginChat) }, sentMsg{BroadcastChatComand, ChatCommandBeginChat, 0, 0}},
+		{"Pit fuel", func() error { return b.PitCommand(PitCommandFuel, 20) }, sentMsg{BroadcastPitCommand, PitCommandFuel, 20, 0}},
+		{"Telemetry restart", func() error { return b.TelemCommand(TelemCommandRestart) }, sentMsg{BroadcastTelemCommand, TelemCommandRestart, 0, 0}},
+		{"FFB max force is 16.16 fixed point", func() error { return b.FFBMaxForce(2.5) }, sentMsg{BroadcastFFBCommand, FFBCommandMaxForce, 0x8000, 0x2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*sent = (*sent)[:0]
+
+			assert.NoError(t, tt.send())
+			assert.Equal(t, []sentMsg{tt.want}, *sent)
+		})
+	}
+}
+
+func TestBroadcasterValidation(t *testing.T) {
+	b, sent := recordBroadcasts(true)
+
+	invalid := map[string]func() error{
+		"Car number not a number":  func() error { return b.FocusOnCar("A1", 0, 0) },
+		"Car number too big":       func() error { return b.FocusOnCar("1000", 0, 0) },
+		"Negative camera group":    func() error { return b.FocusOnCar("1", -1, 0) },
+		"Position zero":            func() error { return b.FocusOnPosition(0, 0, 0) },
+		"Focus at driver":          func() error { return b.FocusAt(CamFocusAtDriver, 0, 0) },
+		"Replay too fast":          func() error { return b.ReplaySetPlaySpeed(17, false) },
+		"Replay search last":       func() error { return b.ReplaySearch(RpySrchLast) },
+		"Replay position last":     func() error { return b.ReplaySetPlayPosition(RpyPosLast, 0) },
+		"Negative session time":    func() error { return b.ReplaySearchSessionTime(0, -time.Second) },
+		"Car index out of range":   func() error { return b.ReloadCarTextures(64) },
+		"Chat macro zero":          func() error { return b.ChatMacro(0) },
+		"Chat macro as command":    func() error { return b.Chat(ChatCommandMacro) },
+		"Unknown pit command":      func() error { return b.PitCommand(12, 0) },
+		"Unknown telemetry action": func() error { return b.TelemCommand(3) },
+		"Negative force":           func() error { return b.FFBMaxForce(-1) },
+	}
+
+	for name, send := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, send(), ErrInvalidBroadcast)
+		})
+	}
+
+	assert.Empty(t, *sent)
+
+	t.Run("Not sent", func(t *testing.T) {
+		b, _ := recordBroadcasts(false)
+
+		assert.ErrorIs(t, b.ReplaySearch(RpySrchToEnd), ErrBroadcastFailed)
+	})
+}
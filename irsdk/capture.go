@@ -0,0 +1,402 @@
+package irsdk
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Capture files hold a whole session recorded from the live memory map, see Recorder and CaptureReader.
+//
+// The gzip compressed stream starts with
+//
+//	magic | version | tickRate | numVars | bufLen | var headers
+//
+// followed by records of
+//
+//	kind | tick | payload length | payload
+//
+// where a session record payload is the sessionInfoUpdate counter and the raw YAML, and a row record
+// payload is the var buffer row for the tick.
+const (
+	CaptureExt = ".ircap"
+
+	captureMagic   = "IRSDKCAP"
+	captureVersion = 1
+
+	captureSession byte = 1
+	captureRow     byte = 2
+
+	captureRecordHeaderSize = 9
+)
+
+// Recorder writes the live telemetry of an SDK to a capture file
+type Recorder struct {
+	sdk         *IRSDK
+	zw          *gzip.Writer
+	every       int
+	started     bool
+	numVars     int
+	bufLen      int
+	clock       tickClock
+	lastTick    int
+	lastWritten int
+	lastSession int
+}
+
+// tickClock keeps recorded ticks increasing when iRacing restarts and counts from the start again
+type tickClock struct {
+	last   int // iRacing tick
+	offset int
+}
+
+// recorded tick of the iRacing tick, carrying on from the last one when the tick goes backwards
+func (c *tickClock) recorded(tick int) int {
+	if tick < c.last {
+		c.offset += c.last - tick + 1
+	}
+
+	c.last = tick
+
+	return tick + c.offset
+}
+
+// NewRecorder records every new tick, or only every Nth tick, to w
+func NewRecorder(sdk *IRSDK, w io.Writer, every int) *Recorder {
+	return &Recorder{
+		sdk:         sdk,
+		zw:          gzip.NewWriter(w),
+		every:       max(every, 1),
+		lastSession: -1,
+	}
+}
+
+// Capture writes the latest tick and any change to the session YAML, returning true if a row was written
+func (rec *Recorder) Capture() (bool, error) {
+	rbuf := make([]byte, varBufsEnd)
+
+	err := readAt(rec.sdk.r, rbuf, 0, "header")
+	if err != nil {
+		return false, err
+	}
+
+	h := parseHeader(rbuf)
+	if !sessionStatusOK(h.status) {
+		// the session is written again, iRacing restarts the sessionInfoUpdate counter
+		rec.lastSession = -1
+
+		return false, nil
+	}
+
+	if !rec.started {
+		err = rec.start(&h)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if h.numVars != rec.numVars || h.bufLen != rec.bufLen {
+		return false, fmt.Errorf("telemetry variables changed during the capture")
+	}
+
+	tick, bufOffset := latestRow(rbuf, &h)
+	tick = rec.clock.recorded(tick)
+
+	if h.sessionInfoUpdate != rec.lastSession {
+		err = rec.writeSession(&h, tick)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if tick <= rec.lastTick || (rec.lastWritten > 0 && tick-rec.lastWritten < rec.every) {
+		return false, nil
+	}
+
+	rec.lastTick = tick
+
+	row := make([]byte, h.bufLen)
+
+	err = readAt(rec.sdk.r, row, int64(bufOffset), "var buffer row")
+	if err != nil {
+		return false, err
+	}
+
+	err = writeRecord(rec.zw, captureRow, tick, row)
+	if err != nil {
+		return false, err
+	}
+
+	rec.lastWritten = tick
+
+	return true, nil
+}
+
+// Close flushes the capture, but does not close the underlying writer
+func (rec *Recorder) Close() error {
+	return rec.zw.Close()
+}
+
+// latestRow of the var buffers in the header and memory map
+func latestRow(rbuf []byte, h *header) (tick, bufOffset int) {
+	for i := 0; i < h.numBuf && i < maxBufs; i++ {
+		vb := rbuf[headerSize+i*varBufSize:]
+		if t := byte4ToInt(vb[0:4]); t > tick {
+			tick, bufOffset = t, byte4ToInt(vb[4:8])
+		}
+	}
+
+	return tick, bufOffset
+}
+
+func (rec *Recorder) start(h *header) error {
+	varHeaders := make([]byte, h.numVars*varHeaderSize)
+
+	err := readAt(rec.sdk.r, varHeaders, int64(h.headerOffset), "variable headers")
+	if err != nil {
+		return err
+	}
+
+	head := make([]byte, len(captureMagic)+16) //nolint:mnd // 4 uint32
+	copy(head, captureMagic)
+	binary.LittleEndian.PutUint32(head[8:], captureVersion)
+	binary.LittleEndian.PutUint32(head[12:], uint32(h.tickRate))
+	binary.LittleEndian.PutUint32(head[16:], uint32(h.numVars))
+	binary.LittleEndian.PutUint32(head[20:], uint32(h.bufLen))
+
+	_, err = rec.zw.Write(append(head, varHeaders...))
+	if err != nil {
+		return err
+	}
+
+	rec.started = true
+	rec.numVars = h.numVars
+	rec.bufLen = h.bufLen
+
+	return nil
+}
+
+func (rec *Recorder) writeSession(h *header, tick int) error {
+	payload := make([]byte, 4+h.sessionInfoLen) //nolint:mnd // update counter
+	binary.LittleEndian.PutUint32(payload, uint32(h.sessionInfoUpdate))
+
+	err := readAt(rec.sdk.r, payload[4:], int64(h.sessionInfoOffset), "session")
+	if err != nil {
+		return err
+	}
+
+	err = writeRecord(rec.zw, captureSession, tick, bytes.TrimRight(payload, "\x00"))
+	if err != nil {
+		return err
+	}
+
+	rec.lastSession = h.sessionInfoUpdate
+
+	return nil
+}
+
+// writeRecord of kind, tick and payload, the record layout of captures and the relay
+func writeRecord(w io.Writer, kind byte, tick int, payload []byte) error {
+	head := make([]byte, captureRecordHeaderSize)
+	head[0] = kind
+	binary.LittleEndian.PutUint32(head[1:5], uint32(tick))
+	binary.LittleEndian.PutUint32(head[5:9], uint32(len(payload)))
+
+	_, err := w.Write(head)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(payload)
+
+	return err
+}
+
+// readRecord written by writeRecord, io.EOF when there are no more records
+func readRecord(r io.Reader) (kind byte, tick int, payload []byte, err error) {
+	head := make([]byte, captureRecordHeaderSize)
+
+	_, err = io.ReadFull(r, head)
+	if errors.Is(err, io.EOF) {
+		return 0, 0, nil, io.EOF
+	}
+
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("short record, err:%w", err)
+	}
+
+	size := byte4ToInt(head[5:9])
+	if size < 0 || size > int(fileMapSize) {
+		return 0, 0, nil, fmt.Errorf("corrupt record of %d bytes", size)
+	}
+
+	payload = make([]byte, size)
+
+	_, err = io.ReadFull(r, payload)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("short record, err:%w", err)
+	}
+
+	return head[0], byte4ToInt(head[1:5]), payload, nil
+}
+
+type captureSessionInfo struct {
+	update int
+	yaml   []byte
+}
+
+type captureFrame struct {
+	tick    int
+	session int // index of the session YAML current at this tick
+	row     []byte
+}
+
+// CaptureReader plays a capture file through the SDK as if it was the live memory map.
+//
+// The whole capture is held in memory, so record long sessions at a reduced rate.
+type CaptureReader struct {
+	player
+	image    memoryImage
+	sessions []captureSessionInfo
+	frames   []captureFrame
+	pos      int64
+}
+
+// OpenCapture loads a capture file for playback
+func OpenCapture(fileName string) (*CaptureReader, error) {
+	f, err := os.Open(fileName) //nolint:gosec // user supplied capture file
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	cr, err := NewCaptureReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("can not read %s, err:%w", fileName, err)
+	}
+
+	return cr, nil
+}
+
+// NewCaptureReader loads a capture ready for playback from the first recorded row
+func NewCaptureReader(r io.Reader) (*CaptureReader, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	defer zr.Close()
+
+	head := make([]byte, len(captureMagic)+16) //nolint:mnd // 4 uint32
+
+	_, err = io.ReadFull(zr, head)
+	if err != nil {
+		return nil, fmt.Errorf("short capture header, err:%w", err)
+	}
+
+	if string(head[:len(captureMagic)]) != captureMagic {
+		return nil, fmt.Errorf("not a capture file")
+	}
+
+	if v := byte4ToInt(head[8:12]); v != captureVersion {
+		return nil, fmt.Errorf("unsupported capture version %d", v)
+	}
+
+	cr := &CaptureReader{
+		image: memoryImage{
+			tickRate: byte4ToInt(head[12:16]),
+			numVars:  byte4ToInt(head[16:20]),
+			bufLen:   byte4ToInt(head[20:24]),
+		},
+	}
+
+	cr.image.varHeaders = make([]byte, cr.image.numVars*varHeaderSize)
+
+	_, err = io.ReadFull(zr, cr.image.varHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("short capture variable headers, err:%w", err)
+	}
+
+	err = cr.readRecords(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cr.frames) == 0 {
+		return nil, fmt.Errorf("no rows in capture")
+	}
+
+	cr.player = newPlayer(len(cr.frames), cr.image.tickRate, func(sample int) int { return cr.frames[sample].tick })
+
+	return cr, nil
+}
+
+func (cr *CaptureReader) readRecords(r io.Reader) error {
+	for {
+		kind, tick, payload, err := readRecord(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("capture %w", err)
+		}
+
+		switch kind {
+		case captureSession:
+			if len(payload) < 4 { //nolint:mnd // update counter
+				return fmt.Errorf("corrupt capture session record")
+			}
+
+			update := byte4ToInt(payload[:4])
+			if n := len(cr.sessions); n > 0 && update <= cr.sessions[n-1].update {
+				// iRacing restarted the counter, keep it increasing so the SDK parses the session again
+				update = cr.sessions[n-1].update + 1
+			}
+
+			cr.sessions = append(cr.sessions, captureSessionInfo{update: update, yaml: payload[4:]})
+			cr.image.sessionSpace = max(cr.image.sessionSpace, len(payload))
+		case captureRow:
+			if len(payload) != cr.image.bufLen {
+				return fmt.Errorf("corrupt capture row at tick %d", tick)
+			}
+
+			cr.frames = append(cr.frames, captureFrame{tick: tick, session: len(cr.sessions) - 1, row: payload})
+		default:
+			return fmt.Errorf("unknown capture record %d", kind)
+		}
+	}
+}
+
+// ReadAt the memory map image for the current sample
+func (cr *CaptureReader) ReadAt(p []byte, off int64) (int, error) {
+	frame := cr.frames[cr.Sample()]
+
+	var session captureSessionInfo
+	if frame.session >= 0 {
+		session = cr.sessions[frame.session]
+	}
+
+	head := cr.image.header(frame.tick, session.update, len(session.yaml))
+
+	return cr.image.readAt(p, off, head, session.yaml, frame.row)
+}
+
+// Read sequentially through the memory map image
+func (cr *CaptureReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadAt(p, cr.pos)
+	cr.pos += int64(n)
+
+	return n, err
+}
+
+// Close does nothing as the capture is held in memory
+func (cr *CaptureReader) Close() error {
+	return nil
+}
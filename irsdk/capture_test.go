@@ -0,0 +1,180 @@
+package irsdk
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordTestIbt(t *testing.T, every int) *bytes.Buffer {
+	t.Helper()
+
+	img := buildTestIbt(60, []float32{10.5, 20.5, 30.5, 40.5}, []int32{1, 1, 2, 2})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	sdk := initTestSDK(t, ibt)
+
+	var buf bytes.Buffer
+
+	rec := NewRecorder(sdk, &buf, every)
+
+	for {
+		_, err := rec.Capture()
+		require.NoError(t, err)
+
+		if !ibt.Step() {
+			break
+		}
+	}
+
+	require.NoError(t, rec.Close())
+
+	return &buf
+}
+
+func TestCaptureEveryTick(t *testing.T) {
+	cr, err := NewCaptureReader(recordTestIbt(t, 1))
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, cr.NumSamples())
+
+	sdk := initTestSDK(t, cr)
+	defer sdk.Close()
+
+	assert.True(t, sdk.IsConnected())
+	assert.Equal(t, 195, sdk.GetSession().WeekendInfo.TrackID)
+
+	speed, err := sdk.GetVarValue("Speed")
+	assert.NoError(t, err)
+	assert.Equal(t, float32(10.5), speed)
+
+	require.NoError(t, cr.Seek(3))
+	assert.True(t, waitForTestData(t, sdk))
+
+	speed, err = sdk.GetVarValue("Speed")
+	assert.NoError(t, err)
+	assert.Equal(t, float32(40.5), speed)
+
+	lap, err := sdk.GetVarValue("Lap")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lap)
+}
+
+func TestCaptureEveryNthTick(t *testing.T) {
+	cr, err := NewCaptureReader(recordTestIbt(t, 2))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, cr.NumSamples())
+
+	sdk := initTestSDK(t, cr)
+	defer sdk.Close()
+
+	assert.True(t, cr.Step())
+	assert.True(t, waitForTestData(t, sdk))
+
+	speed, err := sdk.GetVarValue("Speed")
+	assert.NoError(t, err)
+	assert.Equal(t, float32(30.5), speed)
+
+	assert.False(t, cr.Step())
+}
+
+func TestCaptureRealTimeFollowsRecordedTicks(t *testing.T) {
+	cr, err := NewCaptureReader(recordTestIbt(t, 2))
+	require.NoError(t, err)
+
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	cr.now = func() time.Time { return now }
+
+	cr.RealTime(true)
+
+	now = now.Add(20 * time.Millisecond)
+	assert.Equal(t, 0, cr.Sample(), "next row is 2 ticks later")
+
+	now = now.Add(20 * time.Millisecond)
+	assert.Equal(t, 1, cr.Sample())
+}
+
+func TestCaptureCorrupt(t *testing.T) {
+	_, err := NewCaptureReader(bytes.NewBufferString("not gzip"))
+	assert.Error(t, err)
+
+	buf := recordTestIbt(t, 1)
+
+	_, err = NewCaptureReader(bytes.NewReader(buf.Bytes()[:buf.Len()/2]))
+	assert.Error(t, err)
+}
+
+func TestCaptureIracingRestart(t *testing.T) {
+	img := buildTestIbt(60, []float32{10.5, 20.5, 30.5}, []int32{1, 1, 2})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	sim := &testOffline{reader: ibt}
+	sdk := initTestSDK(t, sim)
+
+	var buf bytes.Buffer
+
+	rec := NewRecorder(sdk, &buf, 1)
+
+	capture := func() bool {
+		written, err := rec.Capture()
+		require.NoError(t, err)
+
+		return written
+	}
+
+	assert.True(t, capture())
+	require.True(t, ibt.Step())
+	assert.True(t, capture())
+
+	sim.off = true
+	assert.False(t, capture())
+
+	// iRacing starts again from the first tick
+	sim.off = false
+	require.NoError(t, ibt.Seek(0))
+	assert.True(t, capture(), "recording carries on")
+	assert.False(t, capture(), "same tick")
+	require.True(t, ibt.Step())
+	assert.True(t, capture())
+
+	require.NoError(t, rec.Close())
+
+	cr, err := NewCaptureReader(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, cr.NumSamples())
+	assert.Len(t, cr.sessions, 2, "session written again after the restart")
+
+	ticks := make([]int, 0, len(cr.frames))
+	for _, frame := range cr.frames {
+		ticks = append(ticks, frame.tick)
+	}
+
+	assert.Equal(t, []int{1, 2, 3, 4}, ticks, "in order for playback")
+
+	player := initTestSDK(t, cr)
+	require.NoError(t, cr.Seek(3))
+	assert.True(t, waitForTestData(t, player))
+	assert.Equal(t, float32(20.5), testSpeed(player))
+}
+
+func TestCaptureShortRead(t *testing.T) {
+	img := buildTestIbt(60, []float32{10.5}, []int32{1})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	sdk := initTestSDK(t, ibt)
+	sdk.r = &tornReader{reader: ibt, size: varBufsEnd / 2}
+
+	_, err = NewRecorder(sdk, &bytes.Buffer{}, 1).Capture()
+	assert.ErrorIs(t, err, ErrShortRead)
+}
@@ -0,0 +1,197 @@
+package irsdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// CatalogEntry describes a telemetry variable from its header, without a value
+type CatalogEntry struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Count       int    `json:"count"`
+	Unit        string `json:"unit"`
+	Desc        string `json:"desc"`
+	CountAsTime bool   `json:"count_as_time"`
+}
+
+// Catalog of the telemetry variables in var buffer order
+type Catalog []CatalogEntry
+
+// Catalog of the variables in the memory map, whether subscribed or not
+func (sdk *IRSDK) Catalog() (Catalog, error) {
+	if !sdk.sessionActive() {
+		return nil, ErrNotConnected
+	}
+
+	sdk.tVars.mux.Lock()
+	defer sdk.tVars.mux.Unlock()
+
+	vars := make([]Variable, 0, len(sdk.tVars.vars))
+	for _, v := range sdk.tVars.vars {
+		vars = append(vars, v)
+	}
+
+	sort.Slice(vars, func(i, j int) bool { return vars[i].offset < vars[j].offset })
+
+	catalog := make(Catalog, 0, len(vars))
+	for _, v := range vars {
+		catalog = append(catalog, CatalogEntry{
+			Name:        v.Name,
+			Type:        v.VarType.String(),
+			Count:       v.Count,
+			Unit:        v.Unit,
+			Desc:        v.Desc,
+			CountAsTime: v.countAsTime,
+		})
+	}
+
+	return catalog, nil
+}
+
+// ReadCatalogJSON written by WriteJSON, e.g. to diff with a live catalog
+func ReadCatalogJSON(r io.Reader) (Catalog, error) {
+	var catalog Catalog
+
+	err := json.NewDecoder(r).Decode(&catalog)
+	if err != nil {
+		return nil, fmt.Errorf("can not decode catalog, err:%w", err)
+	}
+
+	return catalog, nil
+}
+
+// WriteTable aligned in columns
+func (c Catalog) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "NAME\tTYPE\tCOUNT\tUNIT\tTIME\tDESCRIPTION")
+
+	for _, e := range c {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%t\t%s\n", e.Name, e.Type, e.Count, e.Unit, e.CountAsTime, e.Desc)
+	}
+
+	return tw.Flush()
+}
+
+// WriteJSON as an indented array
+func (c Catalog) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(c)
+}
+
+// WriteMarkdown as a table for documentation
+func (c Catalog) WriteMarkdown(w io.Writer) error {
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+
+	_, err := fmt.Fprintln(w, "| Name | Type | Count | Unit | Time | Description |\n|---|---|---:|---|---|---|")
+	if err != nil {
+		return err
+	}
+
+	for _, e := range c {
+		_, err = fmt.Fprintf(w, "| %s | %s | %d | %s | %t | %s |\n",
+			cell.Replace(e.Name), e.Type, e.Count, cell.Replace(e.Unit), e.CountAsTime, cell.Replace(e.Desc))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CatalogChange of a variable in both catalogs
+type CatalogChange struct {
+	From CatalogEntry `json:"from"`
+	To   CatalogEntry `json:"to"`
+}
+
+// CatalogDiff between two catalogs, each sorted by name
+type CatalogDiff struct {
+	Added   []CatalogEntry  `json:"added"`
+	Removed []CatalogEntry  `json:"removed"`
+	Changed []CatalogChange `json:"changed"`
+}
+
+// DiffCatalogs reports the variables added to, removed from or changed in the to catalog
+func DiffCatalogs(from, to Catalog) CatalogDiff {
+	var diff CatalogDiff
+
+	fromByName := make(map[string]CatalogEntry, len(from))
+	for _, e := range from {
+		fromByName[e.Name] = e
+	}
+
+	toByName := make(map[string]CatalogEntry, len(to))
+	for _, e := range to {
+		toByName[e.Name] = e
+
+		was, ok := fromByName[e.Name]
+
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, e)
+		case was != e:
+			diff.Changed = append(diff.Changed, CatalogChange{From: was, To: e})
+		}
+	}
+
+	for _, e := range from {
+		if _, ok := toByName[e.Name]; !ok {
+			diff.Removed = append(diff.Removed, e)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Name < diff.Added[j].Name })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Name < diff.Removed[j].Name })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].To.Name < diff.Changed[j].To.Name })
+
+	return diff
+}
+
+// Empty when the catalogs are the same
+func (d CatalogDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Write the diff one variable per line, + added, - removed, ~ changed
+func (d CatalogDiff) Write(w io.Writer) error {
+	lines := make([]string, 0, len(d.Added)+len(d.Removed)+len(d.Changed))
+
+	for _, e := range d.Added {
+		lines = append(lines, "+ "+e.summary())
+	}
+
+	for _, e := range d.Removed {
+		lines = append(lines, "- "+e.summary())
+	}
+
+	for _, c := range d.Changed {
+		lines = append(lines, "~ "+c.From.summary()+" -> "+c.To.summary())
+	}
+
+	for _, line := range lines {
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// summary e.g. CarIdxLap int[64] "" Laps started by car index
+func (e CatalogEntry) summary() string {
+	countAsTime := ""
+	if e.CountAsTime {
+		countAsTime = " time"
+	}
+
+	return fmt.Sprintf("%s %s[%d] %q%s %s", e.Name, e.Type, e.Count, e.Unit, countAsTime, e.Desc)
+}
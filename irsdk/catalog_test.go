@@ -0,0 +1,104 @@
+package irsdk
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	img := buildTestMemoryMap(3, 2)
+
+	// CarIdxVar1 counts as time and has a unit and a description with a pipe
+	vh := VarHeader{
+		Type: VarTypeFloat, Offset: 2 * 4, Count: 2, CountAsTime: true,
+		Name: "CarIdxVar1", Desc: "Seconds | since start", Unit: "s",
+	}
+	vh.Put(img[varBufsEnd+VarHeaderSize:])
+
+	sdk := initTestSDK(t, memoryMap{bytes.NewReader(img)})
+	sdk.Subscribe("CarIdxVar0")
+
+	catalog, err := sdk.Catalog()
+	require.NoError(t, err)
+
+	assert.Equal(t, Catalog{
+		{Name: "CarIdxVar0", Type: "float", Count: 2},
+		{Name: "CarIdxVar1", Type: "float", Count: 2, Unit: "s", Desc: "Seconds | since start", CountAsTime: true},
+		{Name: "CarIdxVar2", Type: "float", Count: 2},
+	}, catalog, "all variables in var buffer order, whether subscribed or not")
+
+	t.Run("Table", func(t *testing.T) {
+		var out bytes.Buffer
+
+		require.NoError(t, catalog.WriteTable(&out))
+		assert.Equal(t, ""+
+			"NAME        TYPE   COUNT  UNIT  TIME   DESCRIPTION\n"+
+			"CarIdxVar0  float  2            false  \n"+
+			"CarIdxVar1  float  2      s     true   Seconds | since start\n"+
+			"CarIdxVar2  float  2            false  \n", out.String())
+	})
+
+	t.Run("Markdown", func(t *testing.T) {
+		var out bytes.Buffer
+
+		require.NoError(t, catalog.WriteMarkdown(&out))
+		assert.Equal(t, ""+
+			"| Name | Type | Count | Unit | Time | Description |\n"+
+			"|---|---|---:|---|---|---|\n"+
+			"| CarIdxVar0 | float | 2 |  | false |  |\n"+
+			"| CarIdxVar1 | float | 2 | s | true | Seconds \\| since start |\n"+
+			"| CarIdxVar2 | float | 2 |  | false |  |\n", out.String())
+	})
+
+	t.Run("JSON round trip", func(t *testing.T) {
+		var out bytes.Buffer
+
+		require.NoError(t, catalog.WriteJSON(&out))
+		assert.Contains(t, out.String(), `"count_as_time": true`)
+
+		read, err := ReadCatalogJSON(&out)
+		require.NoError(t, err)
+		assert.Equal(t, catalog, read)
+
+		_, err = ReadCatalogJSON(bytes.NewBufferString("{"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Not connected", func(t *testing.T) {
+		_, err := (&IRSDK{}).Catalog()
+		assert.ErrorIs(t, err, ErrNotConnected)
+	})
+}
+
+func TestDiffCatalogs(t *testing.T) {
+	from := Catalog{
+		{Name: "Speed", Type: "float", Count: 1, Unit: "m/s", Desc: "GPS vehicle speed"},
+		{Name: "Lap", Type: "int", Count: 1, Desc: "Laps started count"},
+		{Name: "CarIdxLap", Type: "int", Count: 64, Desc: "Laps started by car index"},
+	}
+
+	to := Catalog{
+		{Name: "CarIdxLap", Type: "int", Count: 64, Desc: "Laps started by car index"},
+		{Name: "Speed", Type: "double", Count: 1, Unit: "m/s", Desc: "GPS vehicle speed"},
+		{Name: "SessionTime", Type: "double", Count: 1, Unit: "s", Desc: "Seconds since session start", CountAsTime: true},
+	}
+
+	diff := DiffCatalogs(from, to)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []CatalogEntry{to[2]}, diff.Added)
+	assert.Equal(t, []CatalogEntry{from[1]}, diff.Removed)
+	assert.Equal(t, []CatalogChange{{From: from[0], To: to[1]}}, diff.Changed)
+
+	var out bytes.Buffer
+
+	require.NoError(t, diff.Write(&out))
+	assert.Equal(t, ""+
+		"+ SessionTime double[1] \"s\" time Seconds since session start\n"+
+		"- Lap int[1] \"\" Laps started count\n"+
+		"~ Speed float[1] \"m/s\" GPS vehicle speed -> Speed double[1] \"m/s\" GPS vehicle speed\n", out.String())
+
+	assert.True(t, DiffCatalogs(to, to).Empty())
+}
@@ -1,6 +1,9 @@
 package irsdk
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 const dataValidEventName string = "Local\\IRSDKDataValidEvent"
 const fileMapName string = "Local\\IRSDKMemMapFileName"
@@ -12,6 +15,16 @@ const (
 	stConnected int = 1
 )
 
+// Memory map and .ibt file layout, see irsdk_defines.h
+const (
+	headerSize        = 48 // irsdk_header up to the varBuf array
+	varBufSize        = 16 // irsdk_varBuf
+	maxBufs           = 4  // IRSDK_MAX_BUFS
+	varBufsEnd        = headerSize + maxBufs*varBufSize
+	varHeaderSize     = 144 // irsdk_varHeader
+	diskSubHeaderSize = 32  // irsdk_diskSubHeader, follows the header in .ibt files
+)
+
 type Msg struct {
 	Cmd int
 	P1  int
@@ -25,6 +38,11 @@ type reader interface {
 	io.Closer
 }
 
+// dataWaiter is a reader that signals new data, in place of the iRacing data valid event
+type dataWaiter interface {
+	WaitForData(timeout time.Duration) bool
+}
+
 const (
 	BroadcastCamSwitchPos            int = 0  // car position, group, camera
 	BroadcastCamSwitchNum            int = 1  // driver #, group, camera
@@ -112,12 +130,9 @@ const (
 
 // irsdk_BroadcastCamSwitchPos or irsdk_BroadcastCamSwitchNum camera focus defines
 // pass these in for the first parameter to select the 'focus at' types in the camera system.
-//
-//nolint:unused // don't care
 const (
-	csFocusAtIncident int = -3
-	csFocusAtLeader   int = -2
-	csFocusAtExiting  int = -1
-	csFocusAtDriver   int = 0 // ctFocusAtDriver + car number...
-
+	CamFocusAtIncident int = -3
+	CamFocusAtLeader   int = -2
+	CamFocusAtExiting  int = -1
+	CamFocusAtDriver   int = 0 // ctFocusAtDriver + car number...
 )
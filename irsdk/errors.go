@@ -0,0 +1,34 @@
+package irsdk
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the SDK, test with errors.Is
+var (
+	ErrNotConnected     = errors.New("iRacing not connected")
+	ErrShortRead        = errors.New("short read from telemetry")
+	ErrCorruptHeader    = errors.New("corrupt telemetry header")
+	ErrUnknownVariable  = errors.New("telemetry variable not found")
+	ErrNotSubscribed    = errors.New("telemetry variable not subscribed")
+	ErrTypeMismatch     = errors.New("telemetry variable type mismatch")
+	ErrInvalidBroadcast = errors.New("invalid broadcast message parameter")
+	ErrBroadcastFailed  = errors.New("broadcast message not sent")
+	ErrSessionYAML      = errors.New("session YAML not parsed")
+	ErrRelayVersion     = errors.New("relay protocol version mismatch")
+)
+
+// readAt fills p or returns ErrShortRead saying what was being read
+func readAt(r reader, p []byte, off int64, what string) error {
+	n, err := r.ReadAt(p, off)
+	if n == len(p) {
+		return nil
+	}
+
+	if err == nil {
+		return fmt.Errorf("%w: %s at offset %d, got %d of %d bytes", ErrShortRead, what, off, n, len(p))
+	}
+
+	return fmt.Errorf("%w: %s at offset %d, err:%w", ErrShortRead, what, off, err)
+}
@@ -0,0 +1,223 @@
+package irsdk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bitfields and enums from irsdk_defines.h, decode with Value, e.g. Value[SessionFlags](sdk, "SessionFlags")
+
+// SessionFlags irsdk_Flags
+type SessionFlags uint32
+
+const (
+	// global flags
+	FlagCheckered     SessionFlags = 0x00000001
+	FlagWhite         SessionFlags = 0x00000002
+	FlagGreen         SessionFlags = 0x00000004
+	FlagYellow        SessionFlags = 0x00000008
+	FlagRed           SessionFlags = 0x00000010
+	FlagBlue          SessionFlags = 0x00000020
+	FlagDebris        SessionFlags = 0x00000040
+	FlagCrossed       SessionFlags = 0x00000080
+	FlagYellowWaving  SessionFlags = 0x00000100
+	FlagOneLapToGreen SessionFlags = 0x00000200
+	FlagGreenHeld     SessionFlags = 0x00000400
+	FlagTenToGo       SessionFlags = 0x00000800
+	FlagFiveToGo      SessionFlags = 0x00001000
+	FlagRandomWaving  SessionFlags = 0x00002000
+	FlagCaution       SessionFlags = 0x00004000
+	FlagCautionWaving SessionFlags = 0x00008000
+
+	// drivers black flags
+	FlagBlack      SessionFlags = 0x00010000
+	FlagDisqualify SessionFlags = 0x00020000
+	FlagServicible SessionFlags = 0x00040000 // car is allowed service (not a flag)
+	FlagFurled     SessionFlags = 0x00080000
+	FlagRepair     SessionFlags = 0x00100000
+
+	// start lights
+	FlagStartHidden SessionFlags = 0x10000000
+	FlagStartReady  SessionFlags = 0x20000000
+	FlagStartSet    SessionFlags = 0x40000000
+	FlagStartGo     SessionFlags = 0x80000000
+)
+
+var sessionFlagNames = []flagName[SessionFlags]{
+	{FlagCheckered, "checkered"},
+	{FlagWhite, "white"},
+	{FlagGreen, "green"},
+	{FlagYellow, "yellow"},
+	{FlagRed, "red"},
+	{FlagBlue, "blue"},
+	{FlagDebris, "debris"},
+	{FlagCrossed, "crossed"},
+	{FlagYellowWaving, "yellowWaving"},
+	{FlagOneLapToGreen, "oneLapToGreen"},
+	{FlagGreenHeld, "greenHeld"},
+	{FlagTenToGo, "tenToGo"},
+	{FlagFiveToGo, "fiveToGo"},
+	{FlagRandomWaving, "randomWaving"},
+	{FlagCaution, "caution"},
+	{FlagCautionWaving, "cautionWaving"},
+	{FlagBlack, "black"},
+	{FlagDisqualify, "disqualify"},
+	{FlagServicible, "servicible"},
+	{FlagFurled, "furled"},
+	{FlagRepair, "repair"},
+	{FlagStartHidden, "startHidden"},
+	{FlagStartReady, "startReady"},
+	{FlagStartSet, "startSet"},
+	{FlagStartGo, "startGo"},
+}
+
+func (f SessionFlags) Has(flag SessionFlags) bool { return f&flag == flag }
+func (f SessionFlags) Names() []string            { return names(f, sessionFlagNames) }
+func (f SessionFlags) String() string             { return strings.Join(f.Names(), "|") }
+
+// EngineWarnings irsdk_EngineWarnings
+type EngineWarnings uint32
+
+const (
+	EngineWarningWaterTemp       EngineWarnings = 0x0001
+	EngineWarningFuelPressure    EngineWarnings = 0x0002
+	EngineWarningOilPressure     EngineWarnings = 0x0004
+	EngineWarningStalled         EngineWarnings = 0x0008
+	EngineWarningPitSpeedLimiter EngineWarnings = 0x0010
+	EngineWarningRevLimiter      EngineWarnings = 0x0020
+	EngineWarningOilTemp         EngineWarnings = 0x0040
+	EngineWarningMandRepNeeded   EngineWarnings = 0x0080 // car needs mandatory repairs
+	EngineWarningOptRepNeeded    EngineWarnings = 0x0100 // car needs optional repairs
+)
+
+var engineWarningNames = []flagName[EngineWarnings]{
+	{EngineWarningWaterTemp, "waterTempWarning"},
+	{EngineWarningFuelPressure, "fuelPressureWarning"},
+	{EngineWarningOilPressure, "oilPressureWarning"},
+	{EngineWarningStalled, "engineStalled"},
+	{EngineWarningPitSpeedLimiter, "pitSpeedLimiter"},
+	{EngineWarningRevLimiter, "revLimiterActive"},
+	{EngineWarningOilTemp, "oilTempWarning"},
+	{EngineWarningMandRepNeeded, "mandRepNeeded"},
+	{EngineWarningOptRepNeeded, "optRepNeeded"},
+}
+
+func (w EngineWarnings) Has(warning EngineWarnings) bool { return w&warning == warning }
+func (w EngineWarnings) Names() []string                 { return names(w, engineWarningNames) }
+func (w EngineWarnings) String() string                  { return strings.Join(w.Names(), "|") }
+
+// CameraState irsdk_CameraState
+type CameraState uint32
+
+const (
+	CameraStateIsSessionScreen       CameraState = 0x0001 // the camera tool can only be activated if viewing the session screen (out of car)
+	CameraStateIsScenicActive        CameraState = 0x0002 // the scenic camera is active (no focus car)
+	CameraStateCamToolActive         CameraState = 0x0004
+	CameraStateUIHidden              CameraState = 0x0008
+	CameraStateUseAutoShotSelection  CameraState = 0x0010
+	CameraStateUseTemporaryEdits     CameraState = 0x0020
+	CameraStateUseKeyAcceleration    CameraState = 0x0040
+	CameraStateUseKey10xAcceleration CameraState = 0x0080
+	CameraStateUseMouseAimMode       CameraState = 0x0100
+)
+
+var cameraStateNames = []flagName[CameraState]{
+	{CameraStateIsSessionScreen, "IsSessionScreen"},
+	{CameraStateIsScenicActive, "IsScenicActive"},
+	{CameraStateCamToolActive, "CamToolActive"},
+	{CameraStateUIHidden, "UIHidden"},
+	{CameraStateUseAutoShotSelection, "UseAutoShotSelection"},
+	{CameraStateUseTemporaryEdits, "UseTemporaryEdits"},
+	{CameraStateUseKeyAcceleration, "UseKeyAcceleration"},
+	{CameraStateUseKey10xAcceleration, "UseKey10xAcceleration"},
+	{CameraStateUseMouseAimMode, "UseMouseAimMode"},
+}
+
+func (c CameraState) Has(state CameraState) bool { return c&state == state }
+func (c CameraState) Names() []string            { return names(c, cameraStateNames) }
+func (c CameraState) String() string             { return strings.Join(c.Names(), "|") }
+
+type flagName[F ~uint32] struct {
+	flag F
+	name string
+}
+
+// names of the set bits, unknown bits are shown in hex
+func names[F ~uint32](f F, known []flagName[F]) []string {
+	result := make([]string, 0)
+
+	for _, fn := range known {
+		if f&fn.flag != 0 {
+			result = append(result, fn.name)
+			f &^= fn.flag
+		}
+	}
+
+	if f != 0 {
+		result = append(result, fmt.Sprintf("0x%x", uint32(f)))
+	}
+
+	return result
+}
+
+// SessionState irsdk_SessionState
+type SessionState int
+
+const (
+	SessionStateInvalid SessionState = iota
+	SessionStateGetInCar
+	SessionStateWarmup
+	SessionStateParadeLaps
+	SessionStateRacing
+	SessionStateCheckered
+	SessionStateCoolDown
+)
+
+func (s SessionState) String() string {
+	switch s {
+	case SessionStateInvalid:
+		return "Invalid"
+	case SessionStateGetInCar:
+		return "GetInCar"
+	case SessionStateWarmup:
+		return "Warmup"
+	case SessionStateParadeLaps:
+		return "ParadeLaps"
+	case SessionStateRacing:
+		return "Racing"
+	case SessionStateCheckered:
+		return "Checkered"
+	case SessionStateCoolDown:
+		return "CoolDown"
+	default:
+		return fmt.Sprintf("SessionState(%d)", int(s))
+	}
+}
+
+// TrkLoc irsdk_TrkLoc, where a car is, e.g. CarIdxTrackSurface
+type TrkLoc int
+
+const (
+	TrkLocNotInWorld     TrkLoc = -1
+	TrkLocOffTrack       TrkLoc = 0
+	TrkLocInPitStall     TrkLoc = 1
+	TrkLocAproachingPits TrkLoc = 2 // pit lane including the entry and exit
+	TrkLocOnTrack        TrkLoc = 3
+)
+
+func (t TrkLoc) String() string {
+	switch t {
+	case TrkLocNotInWorld:
+		return "NotInWorld"
+	case TrkLocOffTrack:
+		return "OffTrack"
+	case TrkLocInPitStall:
+		return "InPitStall"
+	case TrkLocAproachingPits:
+		return "AproachingPits"
+	case TrkLocOnTrack:
+		return "OnTrack"
+	default:
+		return fmt.Sprintf("TrkLoc(%d)", int(t))
+	}
+}
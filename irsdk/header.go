@@ -1,7 +1,7 @@
 package irsdk
 
 import (
-	"log"
+	"fmt"
 )
 
 type header struct {
@@ -22,14 +22,40 @@ type header struct {
 	bufLen int // length in bytes for one line
 }
 
-func readHeader(r reader) header {
-	rbuf := make([]byte, 48) //nolint:mnd // no kidding
+// memHeader to write h back out, without the var buffers
+func (h *header) memHeader() MemHeader {
+	return MemHeader{
+		Version:           h.version,
+		Connected:         sessionStatusOK(h.status),
+		TickRate:          h.tickRate,
+		SessionInfoUpdate: h.sessionInfoUpdate,
+		SessionInfoLen:    h.sessionInfoLen,
+		SessionInfoOffset: h.sessionInfoOffset,
+		NumVars:           h.numVars,
+		VarHeaderOffset:   h.headerOffset,
+		BufLen:            h.bufLen,
+	}
+}
 
-	_, err := r.ReadAt(rbuf, 0)
+func readHeader(r reader) (header, error) {
+	rbuf := make([]byte, headerSize)
+
+	err := readAt(r, rbuf, 0, "header")
 	if err != nil {
-		log.Fatal(err)
+		return header{}, err
+	}
+
+	h := parseHeader(rbuf)
+
+	// iRacing clears the header while it starts up, so only check it once connected
+	if sessionStatusOK(h.status) && (h.numVars <= 0 || h.bufLen <= 0 || h.numBuf <= 0 || h.numBuf > maxBufs) {
+		return header{}, fmt.Errorf("%w: %+v", ErrCorruptHeader, h)
 	}
 
+	return h, nil
+}
+
+func parseHeader(rbuf []byte) header {
 	h := header{
 		byte4ToInt(rbuf[0:4]),
 		byte4ToInt(rbuf[4:8]),
@@ -0,0 +1,438 @@
+package irsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"time"
+)
+
+type diskSubHeader struct {
+	sessionStartDate   int64   // time_t of the session start
+	sessionStartTime   float64 // session time of the first sample
+	sessionEndTime     float64 // session time of the last sample
+	sessionLapCount    int
+	sessionRecordCount int // number of sample rows
+}
+
+func parseDiskSubHeader(rbuf []byte) diskSubHeader {
+	return diskSubHeader{
+		sessionStartDate:   int64(binary.LittleEndian.Uint64(rbuf[0:8])),
+		sessionStartTime:   byte8ToFloat(rbuf[8:16]),
+		sessionEndTime:     byte8ToFloat(rbuf[16:24]),
+		sessionLapCount:    byte4ToInt(rbuf[24:28]),
+		sessionRecordCount: byte4ToInt(rbuf[28:32]),
+	}
+}
+
+// put the disk sub header in the first diskSubHeaderSize bytes of buf
+func (d *diskSubHeader) put(buf []byte) {
+	binary.LittleEndian.PutUint64(buf[0:8], uint64(d.sessionStartDate))
+	binary.LittleEndian.PutUint64(buf[8:16], math.Float64bits(d.sessionStartTime))
+	binary.LittleEndian.PutUint64(buf[16:24], math.Float64bits(d.sessionEndTime))
+	binary.LittleEndian.PutUint32(buf[24:28], uint32(d.sessionLapCount))
+	binary.LittleEndian.PutUint32(buf[28:32], uint32(d.sessionRecordCount))
+}
+
+// IbtReader plays an iRacing .ibt telemetry file through the SDK as if it was the live memory map.
+//
+// The .ibt file shares the memory map header, but the sample rows follow each other on disk instead of
+// rotating through the var buffers. The reader presents the current sample as the only var buffer.
+type IbtReader struct {
+	player
+	r        io.ReaderAt
+	closer   io.Closer
+	h        header
+	disk     diskSubHeader
+	rowStart int64
+	pos      int64
+}
+
+// OpenIbt opens an .ibt file for playback
+func OpenIbt(fileName string) (*IbtReader, error) {
+	f, err := os.Open(fileName) //nolint:gosec // user supplied telemetry file
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	ibt, err := NewIbtReader(f, fi.Size())
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("can not read %s, err:%w", fileName, err)
+	}
+
+	ibt.closer = f
+
+	return ibt, nil
+}
+
+// NewIbtReader reads the headers of an .ibt image of size bytes ready for playback from the first sample
+func NewIbtReader(r io.ReaderAt, size int64) (*IbtReader, error) {
+	rawHead := make([]byte, varBufsEnd+diskSubHeaderSize)
+
+	_, err := r.ReadAt(rawHead, 0)
+	if err != nil {
+		return nil, fmt.Errorf("short .ibt header, err:%w", err)
+	}
+
+	h := parseHeader(rawHead)
+	if h.bufLen <= 0 || h.numVars <= 0 {
+		return nil, fmt.Errorf("corrupt .ibt header %+v", h)
+	}
+
+	vb := rawHead[headerSize : headerSize+varBufSize]
+	rowStart := int64(byte4ToInt(vb[4:8]))
+
+	disk := parseDiskSubHeader(rawHead[varBufsEnd:])
+
+	// iRacing only fills in the record count when the file is closed cleanly
+	records := int((size - rowStart) / int64(h.bufLen))
+	if disk.sessionRecordCount <= 0 || disk.sessionRecordCount > records {
+		disk.sessionRecordCount = records
+	}
+
+	if disk.sessionRecordCount <= 0 {
+		return nil, fmt.Errorf("no samples in .ibt")
+	}
+
+	ibt := &IbtReader{
+		r:        r,
+		h:        h,
+		disk:     disk,
+		rowStart: rowStart,
+	}
+
+	ibt.player = newPlayer(disk.sessionRecordCount, h.tickRate, func(sample int) int { return sample })
+
+	return ibt, nil
+}
+
+// StartDate of the recorded session
+func (ibt *IbtReader) StartDate() time.Time {
+	return time.Unix(ibt.disk.sessionStartDate, 0).UTC()
+}
+
+// SessionTimes covered by the recording in seconds
+func (ibt *IbtReader) SessionTimes() (start, end float64) {
+	return ibt.disk.sessionStartTime, ibt.disk.sessionEndTime
+}
+
+// LapCount recorded in the session
+func (ibt *IbtReader) LapCount() int {
+	return ibt.disk.sessionLapCount
+}
+
+// ReadAt the memory map image for the current sample
+func (ibt *IbtReader) ReadAt(p []byte, off int64) (int, error) {
+	sample := ibt.Sample()
+	head := ibt.memoryHeader(sample)
+	rowEnd := ibt.rowStart + int64(ibt.h.bufLen)
+
+	n := 0
+
+	for n < len(p) {
+		pos := off + int64(n)
+
+		var (
+			m   int
+			err error
+		)
+
+		switch {
+		case pos < int64(len(head)):
+			m = copy(p[n:], head[pos:])
+		case pos < ibt.rowStart:
+			m, err = ibt.r.ReadAt(p[n:min(len(p), n+int(ibt.rowStart-pos))], pos)
+		case pos < rowEnd:
+			sampleOffset := ibt.rowStart + int64(sample)*int64(ibt.h.bufLen)
+			m, err = ibt.r.ReadAt(p[n:min(len(p), n+int(rowEnd-pos))], sampleOffset+pos-ibt.rowStart)
+		default:
+			m, err = ibt.r.ReadAt(p[n:], pos)
+		}
+
+		n += m
+
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Read sequentially through the memory map image
+func (ibt *IbtReader) Read(p []byte) (int, error) {
+	n, err := ibt.ReadAt(p, ibt.pos)
+	ibt.pos += int64(n)
+
+	return n, err
+}
+
+// Close the underlying file
+func (ibt *IbtReader) Close() error {
+	if ibt.closer != nil {
+		return ibt.closer.Close()
+	}
+
+	return nil
+}
+
+// memoryHeader is the file header with a single var buffer, always at the first row, holding the current sample
+func (ibt *IbtReader) memoryHeader(sample int) []byte {
+	mh := ibt.h.memHeader()
+	mh.Connected = true
+	mh.VarBufs = []VarBuffer{{TickCount: sample + 1, BufOffset: int(ibt.rowStart)}}
+
+	head := make([]byte, MemHeaderSize)
+	mh.Put(head)
+
+	return head
+}
+
+// ibtSessionReserve is room for the session YAML to grow while streaming, e.g. with the results
+const ibtSessionReserve = 64 * 1024
+
+// IbtWriter writes the telemetry of an SDK to an .ibt file, appending a sample row for each new tick
+//
+//	header | disk sub header | var headers | session YAML, padded | sample rows
+//
+// Close rewrites the headers with the sample count, session times and the latest session YAML.
+type IbtWriter struct {
+	sdk          *IRSDK
+	w            io.WriteSeeker
+	now          func() time.Time
+	started      bool
+	h            header
+	disk         diskSubHeader
+	sessionTime  *Variable
+	lap          *Variable
+	session      []byte
+	sessionSpace int
+	lastSession  int // sessionInfoUpdate last read, h has the one kept
+	rowStart     int
+	lastTick     int
+}
+
+// NewIbtWriter writes to w, which is not closed by the writer
+func NewIbtWriter(sdk *IRSDK, w io.WriteSeeker) *IbtWriter {
+	return &IbtWriter{
+		sdk: sdk,
+		w:   w,
+		now: time.Now,
+	}
+}
+
+// WriteSample appends the latest tick unless already written, returning true if a row was written
+func (iw *IbtWriter) WriteSample() (bool, error) {
+	h, err := readHeader(iw.sdk.r)
+	if err != nil {
+		return false, err
+	}
+
+	if !sessionStatusOK(h.status) {
+		return false, nil
+	}
+
+	if !iw.started {
+		err = iw.start(&h)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if h.numVars != iw.h.numVars || h.bufLen != iw.h.bufLen {
+		return false, fmt.Errorf("telemetry variables changed during the .ibt")
+	}
+
+	if h.sessionInfoUpdate != iw.lastSession {
+		err = iw.updateSession(&h)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	vb, err := findLatestBuffer(iw.sdk.r, &h)
+	if err != nil {
+		return false, err
+	}
+
+	if vb.TickCount <= iw.lastTick {
+		return false, nil
+	}
+
+	row := make([]byte, h.bufLen)
+
+	err = readAt(iw.sdk.r, row, int64(vb.BufOffset), "var buffer row")
+	if err != nil {
+		return false, err
+	}
+
+	_, err = iw.w.Write(row)
+	if err != nil {
+		return false, err
+	}
+
+	iw.lastTick = vb.TickCount
+	iw.sample(row)
+
+	return true, nil
+}
+
+// Close rewrites the headers, but does not close the underlying writer
+func (iw *IbtWriter) Close() error {
+	if !iw.started {
+		return nil
+	}
+
+	_, err := iw.w.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	_, err = iw.w.Write(iw.header())
+	if err != nil {
+		return err
+	}
+
+	_, err = iw.w.Seek(int64(iw.h.sessionInfoOffset), io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	_, err = iw.w.Write(iw.paddedSession())
+	if err != nil {
+		return err
+	}
+
+	_, err = iw.w.Seek(0, io.SeekEnd)
+
+	return err
+}
+
+// start writes the headers and session, the counts are filled in by Close
+func (iw *IbtWriter) start(h *header) error {
+	varHeaders := make([]byte, h.numVars*varHeaderSize)
+
+	err := readAt(iw.sdk.r, varHeaders, int64(h.headerOffset), "variable headers")
+	if err != nil {
+		return err
+	}
+
+	vars, err := readVariableHeaders(iw.sdk.r, h)
+	if err != nil {
+		return err
+	}
+
+	if v, ok := vars.vars["SessionTime"]; ok && v.VarType == VarTypeDouble {
+		iw.sessionTime = &v
+	}
+
+	if v, ok := vars.vars["Lap"]; ok && v.VarType == VarTypeInt {
+		iw.lap = &v
+	}
+
+	iw.session, err = readRawSession(iw.sdk.r, h)
+	if err != nil {
+		return err
+	}
+
+	iw.h = *h
+	iw.lastSession = h.sessionInfoUpdate
+	iw.h.headerOffset = varBufsEnd + diskSubHeaderSize
+	iw.h.sessionInfoOffset = iw.h.headerOffset + len(varHeaders)
+	iw.sessionSpace = len(iw.session) + ibtSessionReserve
+	iw.rowStart = iw.h.sessionInfoOffset + iw.sessionSpace
+	iw.disk.sessionStartDate = iw.now().Unix()
+
+	for _, part := range [][]byte{iw.header(), varHeaders, iw.paddedSession()} {
+		_, err = iw.w.Write(part)
+		if err != nil {
+			return err
+		}
+	}
+
+	iw.started = true
+
+	return nil
+}
+
+// updateSession keeps the latest session YAML that fits in the space reserved for it
+func (iw *IbtWriter) updateSession(h *header) error {
+	session, err := readRawSession(iw.sdk.r, h)
+	if err != nil {
+		return err
+	}
+
+	iw.lastSession = h.sessionInfoUpdate
+
+	if len(session) > iw.sessionSpace {
+		log.Printf("session update %d of %d bytes too big for the .ibt, keeping update %d",
+			h.sessionInfoUpdate, len(session), iw.h.sessionInfoUpdate)
+
+		return nil
+	}
+
+	iw.h.sessionInfoUpdate = h.sessionInfoUpdate
+	iw.session = session
+
+	return nil
+}
+
+// sample updates the disk sub header from the row written
+func (iw *IbtWriter) sample(row []byte) {
+	if iw.sessionTime != nil {
+		t := byte8ToFloat(row[iw.sessionTime.offset:])
+		if iw.disk.sessionRecordCount == 0 {
+			iw.disk.sessionStartTime = t
+		}
+
+		iw.disk.sessionEndTime = t
+	}
+
+	if iw.lap != nil {
+		iw.disk.sessionLapCount = max(iw.disk.sessionLapCount, byte4ToSignedInt(row[iw.lap.offset:]))
+	}
+
+	iw.disk.sessionRecordCount++
+}
+
+func (iw *IbtWriter) header() []byte {
+	mh := iw.h.memHeader()
+	mh.SessionInfoLen = len(iw.session)
+	mh.VarBufs = []VarBuffer{{TickCount: iw.lastTick, BufOffset: iw.rowStart}}
+
+	head := make([]byte, varBufsEnd+diskSubHeaderSize)
+	mh.Put(head)
+	iw.disk.put(head[varBufsEnd:])
+
+	return head
+}
+
+func (iw *IbtWriter) paddedSession() []byte {
+	padded := make([]byte, iw.sessionSpace)
+	copy(padded, iw.session)
+
+	return padded
+}
+
+// readRawSession is the session YAML as iRacing wrote it, without decoding
+func readRawSession(r reader, h *header) ([]byte, error) {
+	rbuf := make([]byte, h.sessionInfoLen)
+
+	err := readAt(r, rbuf, int64(h.sessionInfoOffset), "session")
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(rbuf, "\x00"), nil
+}
@@ -0,0 +1,274 @@
+package irsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSessionYaml = `---
+WeekendInfo:
+ TrackName: motegi fullcourse
+ TrackID: 195
+ SeriesID: 285
+...
+`
+
+// buildTestIbt lays out an .ibt image with a float Speed and an int Lap per sample
+func buildTestIbt(tickRate int, speeds []float32, laps []int32) []byte {
+	const (
+		numVars = 2
+		bufLen  = 8
+	)
+
+	varHeaderOffset := varBufsEnd + diskSubHeaderSize
+	sessionOffset := varHeaderOffset + numVars*varHeaderSize
+	rowStart := sessionOffset + len(testSessionYaml)
+
+	img := make([]byte, rowStart+len(speeds)*bufLen)
+
+	mh := MemHeader{
+		Version:           2,
+		TickRate:          tickRate,
+		SessionInfoUpdate: 1,
+		SessionInfoLen:    len(testSessionYaml),
+		SessionInfoOffset: sessionOffset,
+		NumVars:           numVars,
+		VarHeaderOffset:   varHeaderOffset,
+		BufLen:            bufLen,
+		VarBufs:           []VarBuffer{{BufOffset: rowStart}},
+	}
+	mh.Put(img)
+
+	disk := diskSubHeader{sessionLapCount: int(laps[len(laps)-1]), sessionRecordCount: len(speeds)}
+	disk.put(img[varBufsEnd:])
+
+	vars := []VarHeader{
+		{Type: VarTypeFloat, Offset: 0, Count: 1, Name: "Speed", Unit: "m/s"},
+		{Type: VarTypeInt, Offset: 4, Count: 1, Name: "Lap"},
+	}
+	for i := range vars {
+		vars[i].Put(img[varHeaderOffset+i*VarHeaderSize:])
+	}
+
+	copy(img[sessionOffset:], testSessionYaml)
+
+	for i := range speeds {
+		binary.LittleEndian.PutUint32(img[rowStart+i*bufLen:], math.Float32bits(speeds[i]))
+		binary.LittleEndian.PutUint32(img[rowStart+i*bufLen+4:], uint32(laps[i]))
+	}
+
+	return img
+}
+
+func TestIbtReader(t *testing.T) {
+	img := buildTestIbt(60, []float32{10.5, 20.5, 30.5}, []int32{1, 1, 2})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, ibt.NumSamples())
+	assert.Equal(t, 2, ibt.LapCount())
+
+	sdk := initTestSDK(t, ibt)
+	defer sdk.Close()
+
+	assert.True(t, sdk.IsConnected())
+	assert.Equal(t, "motegi fullcourse", sdk.GetSession().WeekendInfo.TrackName)
+
+	speed, err := sdk.GetVarValue("Speed")
+	assert.NoError(t, err)
+	assert.Equal(t, float32(10.5), speed)
+
+	t.Run("Step plays the next sample", func(t *testing.T) {
+		assert.True(t, ibt.Step())
+		assert.True(t, waitForTestData(t, sdk))
+
+		speed, err := sdk.GetVarValue("Speed")
+		assert.NoError(t, err)
+		assert.Equal(t, float32(20.5), speed)
+	})
+
+	t.Run("No new data without a step", func(t *testing.T) {
+		assert.False(t, waitForTestData(t, sdk))
+	})
+
+	t.Run("Step stops at the end", func(t *testing.T) {
+		assert.True(t, ibt.Step())
+		assert.False(t, ibt.Step())
+		assert.Equal(t, 2, ibt.Sample())
+	})
+
+	t.Run("Seek", func(t *testing.T) {
+		assert.Error(t, ibt.Seek(3))
+		assert.NoError(t, ibt.Seek(0))
+		assert.Equal(t, 0, ibt.Sample())
+	})
+}
+
+func TestIbtReaderRealTime(t *testing.T) {
+	img := buildTestIbt(60, []float32{10.5, 20.5, 30.5}, []int32{1, 1, 2})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	ibt.now = func() time.Time { return now }
+
+	ibt.RealTime(true)
+	assert.Equal(t, 0, ibt.Sample())
+
+	now = now.Add(20 * time.Millisecond)
+	assert.Equal(t, 1, ibt.Sample())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 2, ibt.Sample())
+
+	sdk := initTestSDK(t, ibt)
+	defer sdk.Close()
+
+	lap, err := sdk.GetVarValue("Lap")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lap)
+}
+
+func TestIbtReaderCorrupt(t *testing.T) {
+	img := make([]byte, varBufsEnd+diskSubHeaderSize)
+
+	_, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	assert.Error(t, err)
+
+	_, err = NewIbtReader(bytes.NewReader(img[:10]), 10)
+	assert.Error(t, err)
+}
+
+func TestIbtWriter(t *testing.T) {
+	speeds := []float32{10.5, 20.5, 30.5}
+	img := buildTestIbt(60, speeds, []int32{1, 1, 2})
+
+	source, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	sdk := initTestSDK(t, source)
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "stream.ibt"))
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	iw := NewIbtWriter(sdk, f)
+	iw.now = func() time.Time { return time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC) }
+
+	for {
+		written, err := iw.WriteSample()
+		require.NoError(t, err)
+		assert.True(t, written)
+
+		written, err = iw.WriteSample()
+		require.NoError(t, err)
+		assert.False(t, written, "the same tick is only written once")
+
+		if !source.Step() {
+			break
+		}
+	}
+
+	require.NoError(t, iw.Close())
+
+	fi, err := f.Stat()
+	require.NoError(t, err)
+
+	ibt, err := NewIbtReader(f, fi.Size())
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, ibt.NumSamples())
+	assert.Equal(t, 2, ibt.LapCount())
+	assert.Equal(t, time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC), ibt.StartDate())
+
+	replay := initTestSDK(t, ibt)
+
+	assert.Equal(t, 195, replay.GetSession().WeekendInfo.TrackID)
+
+	for i := range speeds {
+		require.NoError(t, ibt.Seek(i))
+		assert.True(t, i == 0 || waitForTestData(t, replay))
+
+		speed, err := Value[float32](replay, "Speed")
+		assert.NoError(t, err)
+		assert.Equal(t, speeds[i], speed)
+	}
+
+	t.Run("Export the latest tick", func(t *testing.T) {
+		fileName := filepath.Join(t.TempDir(), "export.ibt")
+
+		require.NoError(t, sdk.ExportIbtTo(fileName))
+
+		ibt, err := OpenIbt(fileName)
+		require.NoError(t, err)
+
+		defer ibt.Close()
+
+		assert.Equal(t, 1, ibt.NumSamples())
+
+		replay := initTestSDK(t, ibt)
+
+		speed, err := Value[float32](replay, "Speed")
+		assert.NoError(t, err)
+		assert.Equal(t, float32(30.5), speed)
+	})
+
+	t.Run("Export when not connected", func(t *testing.T) {
+		sdk := &IRSDK{r: memoryMap{bytes.NewReader(make([]byte, varBufsEnd))}}
+
+		assert.ErrorIs(t, sdk.ExportIbtTo(filepath.Join(t.TempDir(), "export.ibt")), ErrNotConnected)
+	})
+}
+
+func TestIbtWriterSessionTooBig(t *testing.T) {
+	var logged bytes.Buffer
+
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	img := buildTestMemoryMap(1, 1)
+	sdk := initTestSDK(t, memoryMap{bytes.NewReader(img)})
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "stream.ibt"))
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	iw := NewIbtWriter(sdk, f)
+
+	written, err := iw.WriteSample()
+	require.NoError(t, err)
+	assert.True(t, written)
+
+	// no room for any other session
+	iw.sessionSpace = 0
+
+	binary.LittleEndian.PutUint32(img[12:], 2)
+	binary.LittleEndian.PutUint32(img[headerSize:], 2)
+
+	written, err = iw.WriteSample()
+	require.NoError(t, err)
+	assert.True(t, written)
+	assert.Contains(t, logged.String(), "session update 2 of 80 bytes too big for the .ibt, keeping update 1")
+
+	logged.Reset()
+	binary.LittleEndian.PutUint32(img[headerSize:], 3)
+
+	_, err = iw.WriteSample()
+	require.NoError(t, err)
+	assert.Empty(t, logged.String(), "only logged once")
+
+	assert.Equal(t, 1, parseHeader(iw.header()).sessionInfoUpdate, "the update of the session written")
+}
@@ -0,0 +1,87 @@
+package irsdk
+
+import "io"
+
+// memoryImage lays out a memory map from recorded parts so a recording can be played through the SDK
+//
+//	header | var headers | session YAML, padded to sessionSpace | one var buffer row
+type memoryImage struct {
+	tickRate     int
+	numVars      int
+	bufLen       int
+	varHeaders   []byte
+	sessionSpace int
+}
+
+func (m *memoryImage) sessionOffset() int {
+	return varBufsEnd + len(m.varHeaders)
+}
+
+func (m *memoryImage) rowOffset() int {
+	return m.sessionOffset() + m.sessionSpace
+}
+
+// header with a single var buffer holding the row for tick
+func (m *memoryImage) header(tick, sessionUpdate, sessionLen int) []byte {
+	mh := MemHeader{
+		Version:           2, //nolint:mnd // irsdk version
+		Connected:         true,
+		TickRate:          m.tickRate,
+		SessionInfoUpdate: sessionUpdate,
+		SessionInfoLen:    sessionLen,
+		SessionInfoOffset: m.sessionOffset(),
+		NumVars:           m.numVars,
+		VarHeaderOffset:   varBufsEnd,
+		BufLen:            m.bufLen,
+		VarBufs:           []VarBuffer{{TickCount: tick, BufOffset: m.rowOffset()}},
+	}
+
+	head := make([]byte, varBufsEnd)
+	mh.Put(head)
+
+	return head
+}
+
+// readAt the image made up of the header, session and row
+func (m *memoryImage) readAt(p []byte, off int64, head, session, row []byte) (int, error) {
+	segments := []struct {
+		off  int
+		data []byte
+		size int
+	}{
+		{0, head, len(head)},
+		{varBufsEnd, m.varHeaders, len(m.varHeaders)},
+		{m.sessionOffset(), session, m.sessionSpace},
+		{m.rowOffset(), row, m.bufLen},
+	}
+
+	n := 0
+
+	for _, seg := range segments {
+		for n < len(p) {
+			pos := int(off) + n
+			if pos < seg.off || pos >= seg.off+seg.size {
+				break
+			}
+
+			end := min(len(p), n+seg.off+seg.size-pos)
+			part := p[n:end]
+
+			// zero fill anything past the recorded data, like unused shared memory
+			copied := 0
+			if pos-seg.off < len(seg.data) {
+				copied = copy(part, seg.data[pos-seg.off:])
+			}
+
+			clear(part[copied:])
+
+			n = end
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
@@ -2,13 +2,13 @@
 package irsdk
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 	"time"
 
-	"github.com/go-yaml/yaml"
 	"github.com/hidez8891/shm"
 	"github.com/ianhaycox/ir-standings/irsdk/events"
 	"github.com/ianhaycox/ir-standings/irsdk/iryaml"
@@ -19,20 +19,23 @@ const (
 )
 
 type SDK interface {
-	RefreshSession()
-	WaitForData(timeout time.Duration) bool
+	RefreshSession() error
+	WaitForData(timeout time.Duration) (bool, error)
+	Subscribe(names ...string)
 	GetVars() (map[string]Variable, error)
 	GetVar(name string) (Variable, error)
 	GetVarValue(name string) (interface{}, error)
 	GetVarValues(name string) (interface{}, error)
 	GetSession() iryaml.IRSession
 	GetLastVersion() int
+	GetSessionUpdate() int
+	SessionError() error
 	IsConnected() bool
-	ExportIbtTo(fileName string)
-	ExportSessionTo(fileName string)
+	ExportIbtTo(fileName string) error
+	ExportSessionTo(fileName string) error
 	GetYaml() string
 	BroadcastMsg(msg Msg)
-	Close()
+	Close() error
 }
 
 // IRSDK is the main SDK object clients must use
@@ -45,62 +48,204 @@ type IRSDK struct {
 	tVars             *TelemetryVars
 	lastValidData     int64
 	lastSessionUpdate int
+	sessionErr        error           // of the latest session YAML, GetSession has the last good session
+	strictSchema      bool            // log the drift of each session YAML from the iryaml structs
+	subscribed        map[string]bool // only decode these variables, all when nil
 }
 
-func (sdk *IRSDK) RefreshSession() {
-	sRaw := readSessionData(sdk.r, sdk.h)
+// RefreshSession re-reads the header and only parses the session YAML when sessionInfoUpdate has changed
+func (sdk *IRSDK) RefreshSession() error {
+	if sdk.h == nil {
+		return ErrNotConnected
+	}
+
+	h, err := readHeader(sdk.r)
+	if err != nil {
+		return err
+	}
+
+	sdk.h = &h
+
+	if !sessionStatusOK(h.status) {
+		return ErrNotConnected
+	}
+
+	if h.sessionInfoUpdate == sdk.lastSessionUpdate {
+		return nil
+	}
+
+	return sdk.parseSession(&h)
+}
 
-	err := yaml.Unmarshal([]byte(sRaw), &sdk.session)
+// parseSession reads and un-marshals the session YAML, recording which update it is.
+// The previous session is kept when the YAML does not parse and ErrSessionYAML returned.
+func (sdk *IRSDK) parseSession(h *header) error {
+	sRaw, err := readSessionData(sdk.r, h)
 	if err != nil {
-		log.Println(err)
+		return err
 	}
 
 	sdk.s = strings.Split(sRaw, "\n")
+	sdk.lastSessionUpdate = h.sessionInfoUpdate
+
+	session, err := iryaml.Parse(sRaw)
+	if err != nil {
+		sdk.sessionErr = fmt.Errorf("%w: sessionInfoUpdate %d, err:%w", ErrSessionYAML, h.sessionInfoUpdate, err)
+
+		return sdk.sessionErr
+	}
+
+	sdk.session = session
+	sdk.sessionErr = nil
+
+	if sdk.strictSchema {
+		sdk.logSchema(sRaw)
+	}
+
+	return nil
+}
+
+// schemaSections are checked by StrictSchema, CarSetup differs for every car
+var schemaSections = []string{"WeekendInfo", "SessionInfo", "DriverInfo"}
+
+// StrictSchema logs the session YAML keys missing from the iryaml structs, and the struct fields never populated,
+// whenever the session changes. For spotting new iRacing fields while debugging.
+func (sdk *IRSDK) StrictSchema(strict bool) {
+	sdk.strictSchema = strict
+
+	if strict && sdk.s != nil {
+		sdk.logSchema(strings.Join(sdk.s, "\n"))
+	}
+}
+
+func (sdk *IRSDK) logSchema(yml string) {
+	report, err := iryaml.CheckSchema(yml)
+	if err != nil {
+		log.Println("Session schema not checked:", err)
+		return
+	}
+
+	report = report.Filter(schemaSections...)
+	if !report.OK() {
+		log.Printf("Session schema drift at sessionInfoUpdate %d, %s", sdk.lastSessionUpdate, report)
+	}
 }
 
-func (sdk *IRSDK) WaitForData(timeout time.Duration) bool {
+// WaitForData returns true when new telemetry is available, or an error if the memory map can not be read
+func (sdk *IRSDK) WaitForData(timeout time.Duration) (bool, error) {
 	if !sdk.IsConnected() {
-		initIRSDK(sdk)
+		err := initIRSDK(sdk)
+		if err != nil {
+			return false, err
+		}
+
+		if sdk.IsConnected() {
+			// the first data after connecting is read by init
+			return true, nil
+		}
+
+		// init reads the variable headers on the next call once iRacing has connected
+		sdk.waitForEvent(timeout)
+
+		return false, nil
 	}
 
-	if events.WaitForSingleObject(timeout) {
-		sdk.RefreshSession()
+	if sdk.waitForEvent(timeout) {
+		err := sdk.RefreshSession()
+		if errors.Is(err, ErrNotConnected) {
+			return false, nil
+		}
+
+		// telemetry is still good, see SessionError
+		if err != nil && !errors.Is(err, ErrSessionYAML) {
+			return false, err
+		}
+
 		return readVariableValues(sdk)
 	}
 
-	return false
+	return false, nil
 }
 
+// waitForEvent from iRacing that new data is valid, or from the reader when it signals new data itself
+func (sdk *IRSDK) waitForEvent(timeout time.Duration) bool {
+	if w, ok := sdk.r.(dataWaiter); ok {
+		return w.WaitForData(timeout)
+	}
+
+	return events.WaitForSingleObject(timeout)
+}
+
+// Subscribe to the variables decoded on each tick, or all variables if no names are given.
+// GetVar returns ErrNotSubscribed for any other variable.
+func (sdk *IRSDK) Subscribe(names ...string) {
+	var subscribed map[string]bool
+
+	if len(names) > 0 {
+		subscribed = make(map[string]bool, len(names))
+
+		for _, name := range names {
+			subscribed[name] = true
+		}
+	}
+
+	if sdk.tVars == nil {
+		sdk.subscribed = subscribed
+		return
+	}
+
+	sdk.tVars.mux.Lock()
+	defer sdk.tVars.mux.Unlock()
+
+	sdk.subscribed = subscribed
+
+	// the row of the latest tick is still there for newly subscribed variables
+	if sdk.tVars.lastVersion > 0 {
+		for varName := range sdk.tVars.vars {
+			if sdk.isSubscribed(varName) {
+				sdk.tVars.decode(varName)
+			}
+		}
+	}
+}
+
+// GetVars returns the subscribed variables
 func (sdk *IRSDK) GetVars() (map[string]Variable, error) {
 	results := make(map[string]Variable, 0)
 
-	if !sessionStatusOK(sdk.h.status) {
-		return results, fmt.Errorf("session is not active")
+	if !sdk.sessionActive() {
+		return results, ErrNotConnected
 	}
 
 	sdk.tVars.mux.Lock()
 	defer sdk.tVars.mux.Unlock()
 
 	for _, variable := range sdk.tVars.vars {
-		results[variable.Name] = variable
+		if sdk.isSubscribed(variable.Name) {
+			results[variable.Name] = variable.snapshot()
+		}
 	}
 
 	return results, nil
 }
 
 func (sdk *IRSDK) GetVar(name string) (Variable, error) {
-	if !sessionStatusOK(sdk.h.status) {
-		return Variable{}, fmt.Errorf("session is not active")
+	if !sdk.sessionActive() {
+		return Variable{}, ErrNotConnected
 	}
 
 	sdk.tVars.mux.Lock()
 	defer sdk.tVars.mux.Unlock()
 
 	if v, ok := sdk.tVars.vars[name]; ok {
-		return v, nil
+		if !sdk.isSubscribed(name) {
+			return Variable{}, fmt.Errorf("%w: %q", ErrNotSubscribed, name)
+		}
+
+		return v.snapshot(), nil
 	}
 
-	return Variable{}, fmt.Errorf("telemetry variable %q not found", name)
+	return Variable{}, fmt.Errorf("%w: %q", ErrUnknownVariable, name)
 }
 
 func (sdk *IRSDK) GetVarValue(name string) (interface{}, error) {
@@ -134,7 +279,7 @@ func (sdk *IRSDK) GetSession() iryaml.IRSession {
 }
 
 func (sdk *IRSDK) GetLastVersion() int {
-	if !sessionStatusOK(sdk.h.status) {
+	if !sdk.sessionActive() {
 		return -1
 	}
 
@@ -146,12 +291,28 @@ func (sdk *IRSDK) GetLastVersion() int {
 	return last
 }
 
+// GetSessionUpdate is the sessionInfoUpdate counter of the parsed session, it changes whenever GetSession does
+func (sdk *IRSDK) GetSessionUpdate() int {
+	return sdk.lastSessionUpdate
+}
+
+// SessionError is why the latest session YAML did not parse, nil when GetSession is up to date
+func (sdk *IRSDK) SessionError() error {
+	return sdk.sessionErr
+}
+
+// GetSessionData of the parsed session by path, e.g. DriverInfo:Drivers{CarIdx=12}:UserName, see iryaml.Lookup for typed values
 func (sdk *IRSDK) GetSessionData(path string) (string, error) {
-	if !sessionStatusOK(sdk.h.status) {
-		return "", fmt.Errorf("session not connected")
+	if !sdk.sessionActive() {
+		return "", ErrNotConnected
+	}
+
+	value, err := sdk.session.Query(path)
+	if err != nil {
+		return "", err
 	}
 
-	return getSessionDataPath(sdk.s, path)
+	return fmt.Sprint(value), nil
 }
 
 func (sdk *IRSDK) IsConnected() bool {
@@ -164,23 +325,32 @@ func (sdk *IRSDK) IsConnected() bool {
 	return false
 }
 
-// ExportIbtTo exports current memory data to a file
-func (sdk *IRSDK) ExportIbtTo(fileName string) {
-	rbuf := make([]byte, fileMapSize)
-
-	_, err := sdk.r.ReadAt(rbuf, 0)
+// ExportIbtTo exports the latest tick to an .ibt file, use IbtWriter to stream a whole session
+func (sdk *IRSDK) ExportIbtTo(fileName string) error {
+	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, exportFileMode) //nolint:gosec // user supplied
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	_ = os.WriteFile(fileName, rbuf, exportFileMode)
+	iw := NewIbtWriter(sdk, f)
+
+	ok, err := iw.WriteSample()
+	if err == nil && !ok {
+		err = ErrNotConnected
+	}
+
+	if err == nil {
+		err = iw.Close()
+	}
+
+	return errors.Join(err, f.Close())
 }
 
 // ExportSessionTo exports current session yaml data to a file
-func (sdk *IRSDK) ExportSessionTo(fileName string) {
+func (sdk *IRSDK) ExportSessionTo(fileName string) error {
 	y := strings.Join(sdk.s, "\n")
 
-	_ = os.WriteFile(fileName, []byte(y), exportFileMode)
+	return os.WriteFile(fileName, []byte(y), exportFileMode)
 }
 
 func (sdk *IRSDK) GetYaml() string {
@@ -196,52 +366,76 @@ func (sdk *IRSDK) BroadcastMsg(msg Msg) {
 }
 
 // Close clean up sdk resources
-func (sdk *IRSDK) Close() {
-	_ = sdk.r.Close()
+func (sdk *IRSDK) Close() error {
+	return sdk.r.Close()
 }
 
-// Init creates a SDK instance to operate with
-func Init(r reader) *IRSDK {
+// Init creates a SDK instance to operate with, opening the iRacing memory map if r is nil
+func Init(r reader) (*IRSDK, error) {
 	if r == nil {
 		var err error
 
 		r, err = shm.Open(fileMapName, fileMapSize)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("%w: can not open memory map, err:%w", ErrNotConnected, err)
 		}
 	}
 
 	sdk := &IRSDK{r: r, lastValidData: 0}
 
 	events.OpenEvent(dataValidEventName)
-	initIRSDK(sdk)
 
-	return sdk
+	err := initIRSDK(sdk)
+	if err != nil {
+		return sdk, err
+	}
+
+	return sdk, nil
 }
 
-func initIRSDK(sdk *IRSDK) {
-	h := readHeader(sdk.r)
-	sdk.h = &h
+func initIRSDK(sdk *IRSDK) error {
 	sdk.s = nil
 	sdk.lastSessionUpdate = -1
+	sdk.sessionErr = nil
 
 	if sdk.tVars != nil {
 		sdk.tVars.vars = nil
 	}
 
+	h, err := readHeader(sdk.r)
+	sdk.h = &h
+
+	if err != nil {
+		return err
+	}
+
 	if sessionStatusOK(h.status) {
-		sRaw := readSessionData(sdk.r, &h)
+		err = sdk.parseSession(&h)
+		if err != nil && !errors.Is(err, ErrSessionYAML) {
+			return err
+		}
 
-		err := yaml.Unmarshal([]byte(sRaw), &sdk.session)
+		sdk.tVars, err = readVariableHeaders(sdk.r, &h)
 		if err != nil {
-			log.Println(err)
+			return err
 		}
 
-		sdk.s = strings.Split(sRaw, "\n")
-		sdk.tVars = readVariableHeaders(sdk.r, &h)
-
-		readVariableValues(sdk)
+		_, err = readVariableValues(sdk)
+		if err != nil {
+			return err
+		}
 	}
+
+	return nil
+}
+
+func (sdk *IRSDK) isSubscribed(name string) bool {
+	return sdk.subscribed == nil || sdk.subscribed[name]
+}
+
+// sessionActive once the variable headers have been read from a connected session
+func (sdk *IRSDK) sessionActive() bool {
+	return sdk.h != nil && sessionStatusOK(sdk.h.status) && sdk.tVars != nil
 }
 
 func sessionStatusOK(status int) bool {
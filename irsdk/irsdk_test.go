@@ -1,10 +1,204 @@
 package irsdk
 
-import "testing"
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ianhaycox/ir-standings/irsdk/iryaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initTestSDK(t *testing.T, r reader) *IRSDK {
+	t.Helper()
+
+	sdk, err := Init(r)
+	require.NoError(t, err)
+
+	return sdk
+}
+
+func waitForTestData(t *testing.T, sdk *IRSDK) bool {
+	t.Helper()
+
+	ok, err := sdk.WaitForData(time.Millisecond)
+	require.NoError(t, err)
+
+	return ok
+}
 
 func TestInit(t *testing.T) {
 	t.Skip()
 
-	sdk := Init(nil)
+	sdk, err := Init(nil)
+	assert.NoError(t, err)
 	sdk.Close()
 }
+
+func TestTornReads(t *testing.T) {
+	img := buildTestIbt(60, []float32{10.5, 20.5}, []int32{1, 1})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	t.Run("Short header", func(t *testing.T) {
+		_, err := Init(&tornReader{reader: ibt, size: 10})
+		assert.ErrorIs(t, err, ErrShortRead)
+	})
+
+	t.Run("Short variable headers", func(t *testing.T) {
+		_, err := Init(&tornReader{reader: ibt, size: varBufsEnd + diskSubHeaderSize + varHeaderSize})
+		assert.ErrorIs(t, err, ErrShortRead)
+	})
+
+	t.Run("Corrupt header", func(t *testing.T) {
+		corrupt := make([]byte, headerSize)
+		corrupt[4] = byte(stConnected)
+
+		_, err := Init(&tornReader{reader: ibt, corrupt: corrupt})
+		assert.ErrorIs(t, err, ErrCorruptHeader)
+	})
+
+	t.Run("Recovers on the next read", func(t *testing.T) {
+		torn := &tornReader{reader: ibt, size: 10}
+
+		sdk, err := Init(torn)
+		assert.ErrorIs(t, err, ErrShortRead)
+		assert.False(t, sdk.IsConnected())
+
+		_, err = sdk.GetVar("Speed")
+		assert.ErrorIs(t, err, ErrNotConnected)
+
+		torn.size = 0
+
+		assert.True(t, waitForTestData(t, sdk))
+
+		speed, err := sdk.GetVarValue("Speed")
+		assert.NoError(t, err)
+		assert.Equal(t, float32(10.5), speed)
+
+		_, err = sdk.GetVar("Unknown")
+		assert.ErrorIs(t, err, ErrUnknownVariable)
+	})
+}
+
+// tornReader simulates reading the memory map while iRacing is writing it
+type tornReader struct {
+	reader
+	size    int64  // truncate the image to size bytes
+	corrupt []byte // replace the start of the image
+}
+
+func (tr *tornReader) ReadAt(p []byte, off int64) (int, error) {
+	if tr.corrupt != nil && off < int64(len(tr.corrupt)) {
+		return copy(p, tr.corrupt[off:]), nil
+	}
+
+	if tr.size > 0 && off+int64(len(p)) > tr.size {
+		n := max(0, int(tr.size-off))
+		return tr.reader.ReadAt(p[:n], off)
+	}
+
+	return tr.reader.ReadAt(p, off)
+}
+
+func TestRefreshSession(t *testing.T) {
+	img := buildTestMemoryMap(1, 1)
+	sessionOffset := varBufsEnd + varHeaderSize
+
+	sdk := initTestSDK(t, memoryMap{bytes.NewReader(img)})
+	assert.Equal(t, 1, sdk.GetSessionUpdate())
+	assert.Equal(t, 195, sdk.GetSession().WeekendInfo.TrackID)
+
+	nextTick := func(tick int) {
+		binary.LittleEndian.PutUint32(img[headerSize:], uint32(tick))
+		assert.True(t, waitForTestData(t, sdk))
+	}
+
+	copy(img[sessionOffset:], strings.Replace(testSessionYaml, "195", "196", 1))
+
+	t.Run("Session is not parsed until sessionInfoUpdate changes", func(t *testing.T) {
+		nextTick(2)
+
+		assert.Equal(t, 1, sdk.GetSessionUpdate())
+		assert.Equal(t, 195, sdk.GetSession().WeekendInfo.TrackID)
+	})
+
+	t.Run("Session is parsed when sessionInfoUpdate changes", func(t *testing.T) {
+		binary.LittleEndian.PutUint32(img[12:], 2)
+
+		nextTick(3)
+
+		assert.Equal(t, 2, sdk.GetSessionUpdate())
+		assert.Equal(t, 196, sdk.GetSession().WeekendInfo.TrackID)
+
+		trackID, err := sdk.GetSessionData("WeekendInfo:TrackID")
+		assert.NoError(t, err)
+		assert.Equal(t, "196", trackID)
+
+		_, err = sdk.GetSessionData("WeekendInfo:Unknown")
+		assert.ErrorIs(t, err, iryaml.ErrPathNotFound)
+	})
+
+	t.Run("Broken session YAML keeps the last good session", func(t *testing.T) {
+		copy(img[sessionOffset:], strings.Replace(testSessionYaml, "motegi fullcourse", "motegi: fullcours", 1))
+		binary.LittleEndian.PutUint32(img[12:], 3)
+
+		nextTick(4)
+
+		assert.ErrorIs(t, sdk.SessionError(), ErrSessionYAML)
+		assert.Equal(t, 3, sdk.GetSessionUpdate())
+		assert.Equal(t, 196, sdk.GetSession().WeekendInfo.TrackID)
+		assert.Contains(t, sdk.GetYaml(), "motegi: fullcours")
+	})
+
+	t.Run("Fixed session YAML clears the error", func(t *testing.T) {
+		copy(img[sessionOffset:], strings.Replace(testSessionYaml, "195", "197", 1))
+		binary.LittleEndian.PutUint32(img[12:], 4)
+
+		nextTick(5)
+
+		assert.NoError(t, sdk.SessionError())
+		assert.Equal(t, 197, sdk.GetSession().WeekendInfo.TrackID)
+	})
+
+	t.Run("Disconnected", func(t *testing.T) {
+		binary.LittleEndian.PutUint32(img[4:], 0)
+		binary.LittleEndian.PutUint32(img[headerSize:], 6)
+
+		assert.False(t, waitForTestData(t, sdk))
+		assert.False(t, sdk.IsConnected())
+	})
+}
+
+func TestStrictSchema(t *testing.T) {
+	var logged bytes.Buffer
+
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	img := buildTestMemoryMap(1, 1)
+	sdk := initTestSDK(t, memoryMap{bytes.NewReader(img)})
+	assert.Empty(t, logged.String())
+
+	sdk.StrictSchema(true)
+	assert.Contains(t, logged.String(), "Session schema drift at sessionInfoUpdate 1, unknown 0: ; missing ")
+	assert.Contains(t, logged.String(), "WeekendInfo:TrackLength")
+	assert.Contains(t, logged.String(), ", DriverInfo")
+	assert.NotContains(t, logged.String(), "CarSetup")
+
+	t.Run("Logged when the session changes", func(t *testing.T) {
+		logged.Reset()
+
+		binary.LittleEndian.PutUint32(img[12:], 2)
+		binary.LittleEndian.PutUint32(img[headerSize:], 2)
+		assert.True(t, waitForTestData(t, sdk))
+
+		assert.Contains(t, logged.String(), "Session schema drift at sessionInfoUpdate 2")
+	})
+}
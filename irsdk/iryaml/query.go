@@ -0,0 +1,184 @@
+package iryaml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadPath      = errors.New("bad session path")
+	ErrPathNotFound = errors.New("session path not found")
+	ErrWrongType    = errors.New("session value is the wrong type")
+)
+
+const pathSeparator = ":"
+
+// Query the session by a path of YAML keys separated by ':', a list is selected by index or by the value of a key, e.g.
+//
+//	WeekendInfo:TrackID
+//	SessionInfo:Sessions{2}:ResultsPositions{0}:CarIdx
+//	DriverInfo:Drivers{CarIdx=12}:UserName
+func (s *IRSession) Query(path string) (interface{}, error) {
+	v, err := query(reflect.ValueOf(s).Elem(), path)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Interface(), nil
+}
+
+// Lookup queries the session for a value of type T, numbers convert to other numbers of the same kind, e.g. int to int64
+func Lookup[T any](s *IRSession, path string) (T, error) {
+	var result T
+
+	v, err := query(reflect.ValueOf(s).Elem(), path)
+	if err != nil {
+		return result, err
+	}
+
+	want := reflect.TypeOf(&result).Elem()
+
+	switch {
+	case v.Type().AssignableTo(want):
+		reflect.ValueOf(&result).Elem().Set(v)
+	case sameKind(v.Kind(), want.Kind()) && v.Type().ConvertibleTo(want):
+		reflect.ValueOf(&result).Elem().Set(v.Convert(want))
+	default:
+		return result, fmt.Errorf("%w: %s is %s not %s", ErrWrongType, path, v.Type(), want)
+	}
+
+	return result, nil
+}
+
+func query(v reflect.Value, path string) (reflect.Value, error) {
+	if strings.TrimRight(path, pathSeparator) == "" {
+		return v, fmt.Errorf("%w: empty", ErrBadPath)
+	}
+
+	segments := strings.Split(strings.TrimRight(path, pathSeparator), pathSeparator)
+
+	for i, segment := range segments {
+		at := strings.Join(segments[:i+1], pathSeparator)
+
+		key, selector, err := parseSegment(segment)
+		if err != nil {
+			return v, fmt.Errorf("%w: %s, err:%w", ErrBadPath, at, err)
+		}
+
+		if v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct {
+			return v, fmt.Errorf("%w: %s, %s has no keys", ErrPathNotFound, at, strings.Join(segments[:i], pathSeparator))
+		}
+
+		field, ok := fieldByKey(v, key)
+		if !ok {
+			return v, fmt.Errorf("%w: %s, no key %s", ErrPathNotFound, at, key)
+		}
+
+		v = field
+
+		if selector == "" {
+			continue
+		}
+
+		if v.Kind() != reflect.Slice {
+			return v, fmt.Errorf("%w: %s, %s is not a list", ErrBadPath, at, key)
+		}
+
+		v, err = selectElement(v, selector)
+		if err != nil {
+			return v, fmt.Errorf("%s, err:%w", at, err)
+		}
+	}
+
+	return v, nil
+}
+
+// parseSegment splits Key{selector} into the key and the selector
+func parseSegment(segment string) (string, string, error) {
+	open := strings.Index(segment, "{")
+	if open < 0 {
+		if segment == "" || strings.Contains(segment, "}") {
+			return "", "", fmt.Errorf("invalid key %q", segment)
+		}
+
+		return segment, "", nil
+	}
+
+	if open == 0 || !strings.HasSuffix(segment, "}") || strings.Count(segment, "{") != 1 || open+2 == len(segment) {
+		return "", "", fmt.Errorf("invalid selector %q", segment)
+	}
+
+	return segment[:open], segment[open+1 : len(segment)-1], nil
+}
+
+// fieldByKey finds the struct field by its YAML key
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if yamlKey(t.Field(i)) == key {
+			return v.Field(i), true
+		}
+	}
+
+	return v, false
+}
+
+// selectElement of a list by index, {2}, or by the first element with a key value, {CarIdx=12}
+func selectElement(list reflect.Value, selector string) (reflect.Value, error) {
+	key, want, isFilter := strings.Cut(selector, "=")
+	if !isFilter {
+		index, err := strconv.Atoi(selector)
+		if err != nil {
+			return list, fmt.Errorf("%w: index %q is not a number", ErrBadPath, selector)
+		}
+
+		if index < 0 || index >= list.Len() {
+			return list, fmt.Errorf("%w: index %d of %d", ErrPathNotFound, index, list.Len())
+		}
+
+		return list.Index(index), nil
+	}
+
+	for i := 0; i < list.Len(); i++ {
+		elem := list.Index(i)
+		if elem.Kind() != reflect.Struct {
+			return list, fmt.Errorf("%w: elements have no keys to filter by", ErrBadPath)
+		}
+
+		field, ok := fieldByKey(elem, key)
+		if !ok {
+			return list, fmt.Errorf("%w: elements have no key %s", ErrBadPath, key)
+		}
+
+		if fmt.Sprint(field.Interface()) == want {
+			return elem, nil
+		}
+	}
+
+	return list, fmt.Errorf("%w: no element with %s=%s", ErrPathNotFound, key, want)
+}
+
+func sameKind(a, b reflect.Kind) bool {
+	class := func(k reflect.Kind) reflect.Kind {
+		switch k { //nolint:exhaustive // only numbers convert
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return reflect.Int
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return reflect.Uint
+		case reflect.Float32, reflect.Float64:
+			return reflect.Float64
+		default:
+			return k
+		}
+	}
+
+	return class(a) == class(b)
+}
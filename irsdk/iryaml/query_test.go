@@ -0,0 +1,136 @@
+package iryaml
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-yaml/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadFixture(t *testing.T, name string) *IRSession {
+	t.Helper()
+
+	yml, err := os.ReadFile("fixtures/" + name)
+	require.NoError(t, err)
+
+	var session IRSession
+
+	require.NoError(t, yaml.Unmarshal(yml, &session))
+
+	return &session
+}
+
+func TestQuery(t *testing.T) {
+	session := loadFixture(t, "race.yaml")
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"WeekendInfo:TrackID", 195},
+		{"WeekendInfo:TrackDisplayName", "Twin Ring Motegi"},
+		{"WeekendInfo:WeekendOptions:NumStarters", 4},
+		{"WeekendInfo:WeekendOptions:NumStarters:", 4},
+		{"SessionInfo:Sessions{2}:SessionName", "RACE"},
+		{"SessionInfo:Sessions{SessionName=QUALIFY}:ResultsPositions{0}:CarIdx", 2},
+		{"SessionInfo:Sessions{SessionNum=2}:ResultsPositions{CarIdx=4}:ReasonOutStr", "Disconnected"},
+		{"SessionInfo:Sessions{SessionNum=2}:ResultsAverageLapTime", 108.8163},
+		{"SessionInfo:Sessions{0}:SessionSubType", nil},
+		{"DriverInfo:Drivers{CarIdx=2}:UserName", "Sam Racer"},
+		{"DriverInfo:Drivers{CarNumber=007}:CarIdx", 3},
+		{"DriverInfo:Drivers{UserName=Jo Spectator}:IsSpectator", 1},
+		{"DriverInfo:Drivers{CarIdx=1}:CarClassEstLapTime", float32(107.42)},
+		{"CameraInfo:Groups{GroupName=Scenic}:Cameras{0}:CameraName", "Scenic_01"},
+		{"SplitTimeInfo:Sectors{2}:SectorStartPct", 0.664191},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := session.Query(tt.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Structs", func(t *testing.T) {
+		got, err := session.Query("DriverInfo:Drivers{CarIdx=0}")
+		assert.NoError(t, err)
+		assert.Equal(t, "Pace Car", got.(Driver).UserName)
+	})
+}
+
+func TestQueryErrors(t *testing.T) {
+	session := loadFixture(t, "race.yaml")
+
+	tests := []struct {
+		path    string
+		wantErr error
+		wantMsg string
+	}{
+		{"", ErrBadPath, "bad session path: empty"},
+		{"WeekendInfo::TrackID", ErrBadPath, `bad session path: WeekendInfo:, err:invalid key ""`},
+		{"DriverInfo:Drivers{", ErrBadPath, `bad session path: DriverInfo:Drivers{, err:invalid selector "Drivers{"`},
+		{"DriverInfo:Drivers{}", ErrBadPath, `bad session path: DriverInfo:Drivers{}, err:invalid selector "Drivers{}"`},
+		{"DriverInfo:{1}", ErrBadPath, `bad session path: DriverInfo:{1}, err:invalid selector "{1}"`},
+		{"DriverInfo:Drivers{x}", ErrBadPath, `DriverInfo:Drivers{x}, err:bad session path: index "x" is not a number`},
+		{"DriverInfo:Drivers{Car=1}", ErrBadPath, "DriverInfo:Drivers{Car=1}, err:bad session path: elements have no key Car"},
+		{"WeekendInfo:TrackID{0}", ErrBadPath, "bad session path: WeekendInfo:TrackID{0}, TrackID is not a list"},
+		{"WeekendInfo:TrackIdentifier", ErrPathNotFound, "session path not found: WeekendInfo:TrackIdentifier, no key TrackIdentifier"},
+		{"WeekendInfo:TrackID:Name", ErrPathNotFound, "session path not found: WeekendInfo:TrackID:Name, WeekendInfo:TrackID has no keys"},
+		{"SessionInfo:Sessions{3}", ErrPathNotFound, "SessionInfo:Sessions{3}, err:session path not found: index 3 of 3"},
+		{"DriverInfo:Drivers{CarIdx=12}:UserName", ErrPathNotFound, "DriverInfo:Drivers{CarIdx=12}, err:session path not found: no element with CarIdx=12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := session.Query(tt.path)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.EqualError(t, err, tt.wantMsg)
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	session := loadFixture(t, "race.yaml")
+
+	t.Run("Same type", func(t *testing.T) {
+		userName, err := Lookup[string](session, "DriverInfo:Drivers{CarIdx=12}:UserName")
+		assert.ErrorIs(t, err, ErrPathNotFound)
+		assert.Empty(t, userName)
+
+		userName, err = Lookup[string](session, "DriverInfo:Drivers{CarNumber=12}:UserName")
+		assert.NoError(t, err)
+		assert.Equal(t, "Sam Racer", userName)
+	})
+
+	t.Run("Converts numbers of the same kind", func(t *testing.T) {
+		subsessionID, err := Lookup[int64](session, "WeekendInfo:SubSessionID")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(69137722), subsessionID)
+
+		estLapTime, err := Lookup[float64](session, "DriverInfo:Drivers{CarIdx=2}:CarClassEstLapTime")
+		assert.NoError(t, err)
+		assert.InDelta(t, 106.9131, estLapTime, 0.0001)
+	})
+
+	t.Run("Structs and lists", func(t *testing.T) {
+		race, err := Lookup[Session](session, "SessionInfo:Sessions{SessionName=RACE}")
+		assert.NoError(t, err)
+		assert.Len(t, race.ResultsPositions, 4)
+
+		sectors, err := Lookup[[]Sector](session, "SplitTimeInfo:Sectors")
+		assert.NoError(t, err)
+		assert.Len(t, sectors, 3)
+	})
+
+	t.Run("Wrong type", func(t *testing.T) {
+		_, err := Lookup[string](session, "WeekendInfo:TrackID")
+		assert.ErrorIs(t, err, ErrWrongType)
+		assert.EqualError(t, err, "session value is the wrong type: WeekendInfo:TrackID is int not string")
+
+		_, err = Lookup[int](session, "DriverInfo:DriverCarEstLapTime")
+		assert.ErrorIs(t, err, ErrWrongType)
+	})
+}
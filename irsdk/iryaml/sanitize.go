@@ -0,0 +1,73 @@
+package iryaml
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+)
+
+// freeTextKeys are written unquoted by iRacing, user, team and setup names can contain anything, e.g. ': ', ' #' or a leading '@'
+var freeTextKeys = map[string]bool{
+	"UserName":        true,
+	"AbbrevName":      true,
+	"Initials":        true,
+	"TeamName":        true,
+	"DriverSetupName": true,
+	"Notes":           true,
+}
+
+// Parse the session YAML after quoting the free text values
+func Parse(yml string) (IRSession, error) {
+	var session IRSession
+
+	err := yaml.Unmarshal([]byte(Sanitize(yml)), &session)
+	if err != nil {
+		return session, fmt.Errorf("can not parse session YAML, err:%w", err)
+	}
+
+	return session, nil
+}
+
+// Sanitize single quotes the values of free text keys that are not already quoted
+func Sanitize(yml string) string {
+	lines := strings.Split(yml, "\n")
+
+	for i, line := range lines {
+		lines[i] = sanitizeLine(line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func sanitizeLine(line string) string {
+	indent := len(line) - len(strings.TrimLeft(line, " -"))
+
+	key, value, ok := strings.Cut(line[indent:], ": ")
+	if !ok || !freeTextKeys[key] {
+		return line
+	}
+
+	value = strings.TrimRight(value, " \r")
+	if value == "" || isQuoted(value) {
+		return line
+	}
+
+	return line[:indent] + key + ": '" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// isQuoted when the whole value is a single or double quoted scalar, as written by yaml.Marshal
+func isQuoted(value string) bool {
+	if len(value) < 2 {
+		return false
+	}
+
+	switch quote := value[0]; {
+	case quote == '"' && value[len(value)-1] == '"':
+		return !strings.Contains(strings.ReplaceAll(value[1:len(value)-1], `\"`, ""), `"`)
+	case quote == '\'' && value[len(value)-1] == '\'':
+		return !strings.Contains(strings.ReplaceAll(value[1:len(value)-1], "''", ""), "'")
+	default:
+		return false
+	}
+}
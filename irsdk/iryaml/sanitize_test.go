@@ -0,0 +1,139 @@
+package iryaml
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-yaml/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenSession of the free text iRacing writes unquoted into the session YAML
+func brokenSession(setupName, userName, teamName string) string {
+	return fmt.Sprintf(`---
+WeekendInfo:
+ TrackName: motegi fullcourse
+ TrackID: 195
+ TeamRacing: 1
+
+DriverInfo:
+ DriverCarIdx: 1
+ PaceCarIdx: 0
+ DriverSetupName: %s
+ Drivers:
+ - CarIdx: 0
+   UserName: Pace Car
+   AbbrevName:
+   Initials:
+   UserID: -1
+   TeamID: 0
+   TeamName: Pace Car
+   CarNumber: "0"
+   CarIsPaceCar: 1
+ - CarIdx: 1
+   UserName: %s
+   AbbrevName: Driver, A
+   Initials: AD
+   UserID: 100001
+   TeamID: 2001
+   TeamName: %s
+   CarNumber: "7"
+   CarIsPaceCar: 0
+   IRating: 4012
+
+...
+`, setupName, userName, teamName)
+}
+
+func TestParseBrokenSessions(t *testing.T) {
+	const (
+		setupName = "DriverInfo:DriverSetupName"
+		userName  = "DriverInfo:Drivers{CarIdx=1}:UserName"
+		teamName  = "DriverInfo:Drivers{CarIdx=1}:TeamName"
+	)
+
+	team := func(name string) string { return brokenSession("baseline.sto", "Alex Driver", name) }
+	user := func(name string) string { return brokenSession("baseline.sto", name, "Alex Driver") }
+
+	tests := []struct {
+		name string
+		yml  string
+		path string
+		want string
+	}{
+		{"Team colon", team("Apex: Racing Team"), teamName, "Apex: Racing Team"},
+		{"Team hash", team("Team #1 Motorsport"), teamName, "Team #1 Motorsport"},
+		{"Team leading at", team("@home racing"), teamName, "@home racing"},
+		{"Team leading dash", team("- Drift Kings -"), teamName, "- Drift Kings -"},
+		{"Team brackets", team("[GTO] Endurance"), teamName, "[GTO] Endurance"},
+		{"Team braces", team("{ZX} Racing"), teamName, "{ZX} Racing"},
+		{"Team leading star", team("*Stars* Racing"), teamName, "*Stars* Racing"},
+		{"Team leading quote", team("'Sideways' Racing"), teamName, "'Sideways' Racing"},
+		{"Team quoted word", team(`"The" Team`), teamName, `"The" Team`},
+		{"User leading bang", user("!Alex Driver"), userName, "!Alex Driver"},
+		{"User leading percent", user("%Alex Driver"), userName, "%Alex Driver"},
+		{"Setup colon", brokenSession("motegi: wet (low df).sto", "Alex Driver", "Alex Driver"), setupName, "motegi: wet (low df).sto"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw IRSession
+
+			err := yaml.Unmarshal([]byte(tt.yml), &raw)
+			if err == nil {
+				got, _ := Lookup[string](&raw, tt.path)
+				assert.NotEqual(t, tt.want, got, "session is not broken")
+			}
+
+			session, err := Parse(tt.yml)
+			require.NoError(t, err)
+
+			got, err := Lookup[string](&session, tt.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, 195, session.WeekendInfo.TrackID)
+			assert.Equal(t, "Driver, A", session.DriverInfo.Drivers[1].AbbrevName)
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"Plain value", " - TeamName: Apex: Racing", " - TeamName: 'Apex: Racing'"},
+		{"Single quotes doubled", "   UserName: Bob's Team", "   UserName: 'Bob''s Team'"},
+		{"Trailing space and CR", "   TeamName: Apex \r", "   TeamName: 'Apex'"},
+		{"Empty", "   AbbrevName:", "   AbbrevName:"},
+		{"Empty with space", "   Initials: ", "   Initials: "},
+		{"Single quoted by yaml.Marshal", `   UserName: '@home'`, `   UserName: '@home'`},
+		{"Double quoted with escapes", `   TeamName: "a \"b\""`, `   TeamName: "a \"b\""`},
+		{"Only starts with a quote", `   TeamName: "The" Team`, `   TeamName: '"The" Team'`},
+		{"Not free text", "   CarScreenName: Audi 90 GTO", "   CarScreenName: Audi 90 GTO"},
+		{"Key prefix", "   UserNameX: a: b", "   UserNameX: a: b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Sanitize(tt.line))
+		})
+	}
+
+	t.Run("Marshalled sessions parse to the same values", func(t *testing.T) {
+		session := IRSession{DriverInfo: DriverInfo{Drivers: []Driver{{UserName: "@home: #1", TeamName: "it's", AbbrevName: `"q"`}}}}
+
+		yml, err := yaml.Marshal(session)
+		require.NoError(t, err)
+		parsed, err := Parse(string(yml))
+		require.NoError(t, err)
+		assert.Equal(t, session.DriverInfo.Drivers, parsed.DriverInfo.Drivers)
+	})
+
+	t.Run("Still broken", func(t *testing.T) {
+		_, err := Parse("WeekendInfo:\n TrackName: motegi: fullcourse\n")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,171 @@
+package iryaml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+)
+
+// listElements in a schema path stands for any element of a list, e.g. DriverInfo:Drivers{}:UserName
+const listElements = "{}"
+
+// SchemaReport of the drift between a session YAML and the IRSession structs, as paths of YAML keys
+type SchemaReport struct {
+	Unknown []string // keys in the YAML with no struct field
+	Missing []string // struct fields with no key in the YAML, in any element of a list
+}
+
+// OK when the YAML and the structs match
+func (r SchemaReport) OK() bool {
+	return len(r.Unknown) == 0 && len(r.Missing) == 0
+}
+
+// String on one line for logging
+func (r SchemaReport) String() string {
+	if r.OK() {
+		return "session schema OK"
+	}
+
+	return fmt.Sprintf("unknown %d: %s; missing %d: %s",
+		len(r.Unknown), strings.Join(r.Unknown, ", "), len(r.Missing), strings.Join(r.Missing, ", "))
+}
+
+// Filter the report to the paths under the top level sections, e.g. DriverInfo
+func (r SchemaReport) Filter(sections ...string) SchemaReport {
+	under := func(paths []string) []string {
+		var result []string
+
+		for _, path := range paths {
+			for _, section := range sections {
+				if path == section || strings.HasPrefix(path, section+pathSeparator) {
+					result = append(result, path)
+					break
+				}
+			}
+		}
+
+		return result
+	}
+
+	return SchemaReport{Unknown: under(r.Unknown), Missing: under(r.Missing)}
+}
+
+// CheckSchema compares the keys of the session YAML with the IRSession structs
+func CheckSchema(yml string) (SchemaReport, error) {
+	var doc yaml.MapSlice
+
+	err := yaml.Unmarshal([]byte(Sanitize(yml)), &doc)
+	if err != nil {
+		return SchemaReport{}, fmt.Errorf("can not parse session YAML, err:%w", err)
+	}
+
+	return checkNode(reflect.TypeOf(IRSession{}), doc, ""), nil
+}
+
+func checkNode(t reflect.Type, node interface{}, path string) SchemaReport {
+	switch t.Kind() { //nolint:exhaustive // only structs have keys
+	case reflect.Struct:
+		return checkStruct(t, node, path)
+	case reflect.Slice:
+		return checkList(t.Elem(), node, path+listElements)
+	default:
+		return SchemaReport{}
+	}
+}
+
+func checkStruct(t reflect.Type, node interface{}, path string) SchemaReport {
+	var report SchemaReport
+
+	keys, ok := node.(yaml.MapSlice)
+	if !ok {
+		return report
+	}
+
+	seen := make(map[string]bool, len(keys))
+
+	for _, item := range keys {
+		key := fmt.Sprint(item.Key)
+		seen[key] = true
+
+		field, ok := structField(t, key)
+		if !ok {
+			report.Unknown = append(report.Unknown, join(path, key))
+			continue
+		}
+
+		child := checkNode(field.Type, item.Value, join(path, key))
+		report.Unknown = append(report.Unknown, child.Unknown...)
+		report.Missing = append(report.Missing, child.Missing...)
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if key := yamlKey(t.Field(i)); !seen[key] {
+			report.Missing = append(report.Missing, join(path, key))
+		}
+	}
+
+	return report
+}
+
+// checkList reports keys unknown in any element, and fields missing from every element
+func checkList(t reflect.Type, node interface{}, path string) SchemaReport {
+	var report SchemaReport
+
+	elems, ok := node.([]interface{})
+	if !ok || len(elems) == 0 {
+		return report
+	}
+
+	unknown := make(map[string]bool)
+	missing := make(map[string]int)
+
+	for _, elem := range elems {
+		child := checkNode(t, elem, path)
+
+		for _, p := range child.Unknown {
+			if !unknown[p] {
+				unknown[p] = true
+				report.Unknown = append(report.Unknown, p)
+			}
+		}
+
+		for _, p := range child.Missing {
+			missing[p]++
+			if missing[p] == len(elems) {
+				report.Missing = append(report.Missing, p)
+			}
+		}
+	}
+
+	return report
+}
+
+func structField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if yamlKey(t.Field(i)) == key {
+			return t.Field(i), true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// yamlKey of a struct field, as go-yaml names it
+func yamlKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	return name
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + pathSeparator + key
+}
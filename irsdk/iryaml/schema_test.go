@@ -0,0 +1,76 @@
+package iryaml
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const driftedYaml = `---
+WeekendInfo:
+ TrackName: motegi fullcourse
+ TrackID: 195
+ TrackSurfaceType: asphalt
+ WeekendOptions:
+  NumStarters: 4
+DriverInfo:
+ DriverCarIdx: 1
+ Drivers:
+ - CarIdx: 0
+   UserName: Pace Car
+ - CarIdx: 1
+   UserName: Alex: Driver
+   FlairName: Japan
+   IRating: 4012
+SessionInfo:
+ Sessions: []
+NewSection:
+ Value: 1
+...
+`
+
+func TestCheckSchema(t *testing.T) {
+	t.Run("Fixture", func(t *testing.T) {
+		yml, err := os.ReadFile("fixtures/race.yaml")
+		require.NoError(t, err)
+
+		report, err := CheckSchema(string(yml))
+		require.NoError(t, err)
+
+		assert.Empty(t, report.Unknown)
+		assert.Equal(t, []string{
+			"CarSetup:Chassis:LeftFront", "CarSetup:Chassis:LeftRear", "CarSetup:Chassis:RightFront", "CarSetup:Chassis:RightRear",
+		}, report.Missing)
+		assert.False(t, report.OK())
+		assert.True(t, report.Filter("DriverInfo", "WeekendInfo", "SessionInfo").OK())
+	})
+
+	t.Run("Drifted", func(t *testing.T) {
+		report, err := CheckSchema(driftedYaml)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"WeekendInfo:TrackSurfaceType", "DriverInfo:Drivers{}:FlairName", "NewSection"}, report.Unknown)
+		assert.Contains(t, report.Missing, "WeekendInfo:TrackLength")
+		assert.Contains(t, report.Missing, "WeekendInfo:WeekendOptions:StartingGrid")
+		assert.Contains(t, report.Missing, "DriverInfo:Drivers{}:TeamName")
+		assert.NotContains(t, report.Missing, "DriverInfo:Drivers{}:IRating", "populated in one element")
+		assert.NotContains(t, report.Missing, "SessionInfo:Sessions{}:SessionNum", "empty list")
+		assert.Contains(t, report.Missing, "CarSetup")
+		assert.NotContains(t, report.Missing, "CarSetup:UpdateCount", "only the missing section")
+
+		driverInfo := report.Filter("DriverInfo")
+		assert.Equal(t, []string{"DriverInfo:Drivers{}:FlairName"}, driverInfo.Unknown)
+		assert.Contains(t, driverInfo.String(), "unknown 1: DriverInfo:Drivers{}:FlairName; missing ")
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		assert.Equal(t, "session schema OK", SchemaReport{}.String())
+	})
+
+	t.Run("Not YAML", func(t *testing.T) {
+		_, err := CheckSchema("WeekendInfo:\n TrackName: a\n  TrackID: 1\n")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,284 @@
+package iryaml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadMeasurement    = errors.New("can not parse measurement")
+	ErrIncompatibleUnits = errors.New("incompatible units")
+)
+
+// Unit of a measurement as iRacing writes it
+type Unit string
+
+// Units in the session YAML, iRacing uses metric or imperial depending on the driver's display settings
+const (
+	Unitless        Unit = ""
+	Meters          Unit = "m"
+	Kilometers      Unit = "km"
+	Millimeters     Unit = "mm"
+	Centimeters     Unit = "cm"
+	Inches          Unit = "in"
+	Feet            Unit = "ft"
+	Miles           Unit = "mi"
+	Celsius         Unit = "C"
+	Fahrenheit      Unit = "F"
+	MetersPerSecond Unit = "m/s"
+	KPH             Unit = "kph"
+	MPH             Unit = "mph"
+	KPa             Unit = "kPa"
+	PSI             Unit = "psi"
+	InHg            Unit = "Hg"
+	Millibars       Unit = "mb"
+	Radians         Unit = "rad"
+	Degrees         Unit = "deg"
+	Percent         Unit = "%"
+	Liters          Unit = "L"
+	Gallons         Unit = "gal"
+	Kilograms       Unit = "kg"
+	Pounds          Unit = "lbs"
+	Newtons         Unit = "N"
+	NewtonMeters    Unit = "Nm"
+	PoundFeet       Unit = "ft-lbs"
+	NewtonsPerMM    Unit = "N/mm"
+	PoundsPerInch   Unit = "lbs/in"
+	Clicks          Unit = "clicks"
+)
+
+type dimension int
+
+const (
+	dimNone dimension = iota
+	dimLength
+	dimTemperature
+	dimSpeed
+	dimPressure
+	dimAngle
+	dimRatio
+	dimVolume
+	dimMass
+	dimForce
+	dimTorque
+	dimSpringRate
+	dimClicks
+)
+
+// scale converts to the base unit of the dimension, base = value*factor + offset
+type scale struct {
+	dim    dimension
+	factor float64
+	offset float64
+}
+
+var units = map[Unit]scale{
+	Unitless:        {dimNone, 1, 0},
+	Meters:          {dimLength, 1, 0},
+	Kilometers:      {dimLength, 1000, 0},
+	Millimeters:     {dimLength, 0.001, 0},
+	Centimeters:     {dimLength, 0.01, 0},
+	Inches:          {dimLength, 0.0254, 0},
+	Feet:            {dimLength, 0.3048, 0},
+	Miles:           {dimLength, 1609.344, 0},
+	Celsius:         {dimTemperature, 1, 0},
+	Fahrenheit:      {dimTemperature, 5.0 / 9, -32 * 5.0 / 9},
+	MetersPerSecond: {dimSpeed, 1, 0},
+	KPH:             {dimSpeed, 1 / 3.6, 0},
+	MPH:             {dimSpeed, 0.44704, 0},
+	KPa:             {dimPressure, 1, 0},
+	PSI:             {dimPressure, 6.894757, 0},
+	InHg:            {dimPressure, 3.386389, 0},
+	Millibars:       {dimPressure, 0.1, 0},
+	Radians:         {dimAngle, 1, 0},
+	Degrees:         {dimAngle, math.Pi / 180, 0},
+	Percent:         {dimRatio, 1, 0},
+	Liters:          {dimVolume, 1, 0},
+	Gallons:         {dimVolume, 3.785411784, 0},
+	Kilograms:       {dimMass, 1, 0},
+	Pounds:          {dimMass, 0.45359237, 0},
+	Newtons:         {dimForce, 1, 0},
+	NewtonMeters:    {dimTorque, 1, 0},
+	PoundFeet:       {dimTorque, 1.3558179483, 0},
+	NewtonsPerMM:    {dimSpringRate, 1, 0},
+	PoundsPerInch:   {dimSpringRate, 0.175126835, 0},
+	Clicks:          {dimClicks, 1, 0},
+}
+
+// unitAliases are other spellings of the same unit
+var unitAliases = map[string]Unit{
+	"km/h":  KPH,
+	`"`:     Inches,
+	"l":     Liters,
+	"lb":    Pounds,
+	"hPa":   Millibars,
+	"click": Clicks,
+}
+
+// Measurement is a value and its unit, e.g. "5.51 km"
+type Measurement struct {
+	Value float64
+	Unit  Unit
+}
+
+// ParseMeasurement of a value with an optional unit, with or without a space, e.g. "25.3 C", "28C", "56.0%" or -1/16"
+func ParseMeasurement(s string) (Measurement, error) {
+	trimmed := strings.TrimSpace(s)
+
+	end := strings.IndexFunc(trimmed, func(r rune) bool {
+		return !strings.ContainsRune("0123456789+-./", r)
+	})
+	if end < 0 {
+		end = len(trimmed)
+	}
+
+	value, err := parseNumber(trimmed[:end])
+	if err != nil {
+		return Measurement{}, fmt.Errorf("%w: %q", ErrBadMeasurement, s)
+	}
+
+	unit := Unit(strings.TrimSpace(trimmed[end:]))
+	if alias, ok := unitAliases[string(unit)]; ok {
+		unit = alias
+	}
+
+	if _, ok := units[unit]; !ok {
+		return Measurement{}, fmt.Errorf("%w: %q, unknown unit %q", ErrBadMeasurement, s, unit)
+	}
+
+	return Measurement{Value: value, Unit: unit}, nil
+}
+
+// ParseMeasurements of a comma separated list, e.g. tyre temperatures "28C, 29C, 30C"
+func ParseMeasurements(s string) ([]Measurement, error) {
+	parts := strings.Split(s, ",")
+	result := make([]Measurement, 0, len(parts))
+
+	for _, part := range parts {
+		m, err := ParseMeasurement(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrBadMeasurement, s)
+		}
+
+		result = append(result, m)
+	}
+
+	return result, nil
+}
+
+// parseNumber of a decimal or a fraction, e.g. 1/16
+func parseNumber(s string) (float64, error) {
+	numerator, denominator, isFraction := strings.Cut(s, "/")
+	if !isFraction {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	n, err := strconv.ParseFloat(numerator, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	d, err := strconv.ParseFloat(denominator, 64)
+	if err != nil || d == 0 {
+		return 0, fmt.Errorf("bad fraction %s", s)
+	}
+
+	return n / d, nil
+}
+
+// To converts the measurement to another unit of the same dimension, e.g. km to mi
+func (m Measurement) To(unit Unit) (Measurement, error) {
+	from, ok := units[m.Unit]
+	if !ok {
+		return Measurement{}, fmt.Errorf("%w: unknown unit %q", ErrIncompatibleUnits, m.Unit)
+	}
+
+	to, ok := units[unit]
+	if !ok {
+		return Measurement{}, fmt.Errorf("%w: unknown unit %q", ErrIncompatibleUnits, unit)
+	}
+
+	if from.dim != to.dim {
+		return Measurement{}, fmt.Errorf("%w: %s to %s", ErrIncompatibleUnits, m.Unit, unit)
+	}
+
+	base := m.Value*from.factor + from.offset
+
+	return Measurement{Value: (base - to.offset) / to.factor, Unit: unit}, nil
+}
+
+func (m Measurement) String() string {
+	value := strconv.FormatFloat(m.Value, 'f', -1, 64)
+
+	switch m.Unit {
+	case Unitless:
+		return value
+	case Percent:
+		return value + string(m.Unit)
+	default:
+		return value + " " + string(m.Unit)
+	}
+}
+
+// Measure the string at the path of the session, e.g. CarSetup:TiresAero:LeftFront:StartingPressure
+func Measure(s *IRSession, path string) (Measurement, error) {
+	value, err := Lookup[string](s, path)
+	if err != nil {
+		return Measurement{}, err
+	}
+
+	m, err := ParseMeasurement(value)
+	if err != nil {
+		return Measurement{}, fmt.Errorf("%s, err:%w", path, err)
+	}
+
+	return m, nil
+}
+
+// Length of the track
+func (w *WeekendInfo) Length() (Measurement, error) {
+	return ParseMeasurement(w.TrackLength)
+}
+
+// Altitude of the track
+func (w *WeekendInfo) Altitude() (Measurement, error) {
+	return ParseMeasurement(w.TrackAltitude)
+}
+
+// PitSpeedLimit of the track
+func (w *WeekendInfo) PitSpeedLimit() (Measurement, error) {
+	return ParseMeasurement(w.TrackPitSpeedLimit)
+}
+
+// AirTemp at the start of the session
+func (w *WeekendInfo) AirTemp() (Measurement, error) {
+	return ParseMeasurement(w.TrackAirTemp)
+}
+
+// SurfaceTemp of the track at the start of the session
+func (w *WeekendInfo) SurfaceTemp() (Measurement, error) {
+	return ParseMeasurement(w.TrackSurfaceTemp)
+}
+
+// AirPressure at the start of the session
+func (w *WeekendInfo) AirPressure() (Measurement, error) {
+	return ParseMeasurement(w.TrackAirPressure)
+}
+
+// WindSpeed at the start of the session
+func (w *WeekendInfo) WindSpeed() (Measurement, error) {
+	return ParseMeasurement(w.TrackWindVel)
+}
+
+// WindDirection at the start of the session, radians
+func (w *WeekendInfo) WindDirection() (Measurement, error) {
+	return ParseMeasurement(w.TrackWindDir)
+}
+
+// RelativeHumidity at the start of the session
+func (w *WeekendInfo) RelativeHumidity() (Measurement, error) {
+	return ParseMeasurement(w.TrackRelativeHumidity)
+}
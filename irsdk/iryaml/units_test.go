@@ -0,0 +1,165 @@
+package iryaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMeasurement(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Measurement
+	}{
+		{"5.51 km", Measurement{5.51, Kilometers}},
+		{"25.3 C", Measurement{25.3, Celsius}},
+		{"72.00 kph", Measurement{72, KPH}},
+		{"3.22 km/h", Measurement{3.22, KPH}},
+		{"0.89 m/s", Measurement{0.89, MetersPerSecond}},
+		{"29.29 Hg", Measurement{29.29, InHg}},
+		{"152 kPa", Measurement{152, KPa}},
+		{"28C", Measurement{28, Celsius}},
+		{"56.0%", Measurement{56, Percent}},
+		{"45 %", Measurement{45, Percent}},
+		{"-2.8 deg", Measurement{-2.8, Degrees}},
+		{"+6.5 deg", Measurement{6.5, Degrees}},
+		{`-1/16"`, Measurement{-0.0625, Inches}},
+		{"630 N/mm", Measurement{630, NewtonsPerMM}},
+		{"1200 lbs/in", Measurement{1200, PoundsPerInch}},
+		{"75 Nm", Measurement{75, NewtonMeters}},
+		{"-12 clicks", Measurement{-12, Clicks}},
+		{" 1 ", Measurement{1, Unitless}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseMeasurement(tt.s)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.want.Value, got.Value, 1e-9)
+			assert.Equal(t, tt.want.Unit, got.Unit)
+		})
+	}
+
+	for _, s := range []string{"", "unlimited", "1 (ABS)", "Race 1", "1/0 in", "12 parsecs", "1.2.3 m"} {
+		t.Run("Bad "+s, func(t *testing.T) {
+			_, err := ParseMeasurement(s)
+			assert.ErrorIs(t, err, ErrBadMeasurement)
+			assert.Contains(t, err.Error(), `"`+s+`"`, "reports the original string")
+		})
+	}
+}
+
+func TestParseMeasurements(t *testing.T) {
+	got, err := ParseMeasurements("28C, 29C, 30C")
+	assert.NoError(t, err)
+	assert.Equal(t, []Measurement{{28, Celsius}, {29, Celsius}, {30, Celsius}}, got)
+
+	_, err = ParseMeasurements("100%, worn, 98%")
+	assert.ErrorIs(t, err, ErrBadMeasurement)
+	assert.EqualError(t, err, `can not parse measurement: "100%, worn, 98%"`)
+}
+
+func TestMeasurementTo(t *testing.T) {
+	tests := []struct {
+		name string
+		from Measurement
+		to   Unit
+		want float64
+	}{
+		{"km to mi", Measurement{5.51, Kilometers}, Miles, 3.4237},
+		{"C to F", Measurement{25.3, Celsius}, Fahrenheit, 77.54},
+		{"F to C", Measurement{-40, Fahrenheit}, Celsius, -40},
+		{"kph to mph", Measurement{72, KPH}, MPH, 44.7388},
+		{"kPa to psi", Measurement{152, KPa}, PSI, 22.0456},
+		{"Hg to mb", Measurement{29.92, InHg}, Millibars, 1013.2076},
+		{"rad to deg", Measurement{3.14159265, Radians}, Degrees, 180},
+		{"L to gal", Measurement{45, Liters}, Gallons, 11.8877},
+		{"kg to lbs", Measurement{10, Kilograms}, Pounds, 22.0462},
+		{"N/mm to lbs/in", Measurement{175.126835, NewtonsPerMM}, PoundsPerInch, 1000},
+		{"in to mm", Measurement{1, Inches}, Millimeters, 25.4},
+		{"Same unit", Measurement{1, Percent}, Percent, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.from.To(tt.to)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.want, got.Value, 0.001)
+			assert.Equal(t, tt.to, got.Unit)
+		})
+	}
+
+	t.Run("Incompatible", func(t *testing.T) {
+		_, err := Measurement{1, Kilometers}.To(Celsius)
+		assert.ErrorIs(t, err, ErrIncompatibleUnits)
+		assert.EqualError(t, err, "incompatible units: km to C")
+
+		_, err = Measurement{1, Unit("furlong")}.To(Meters)
+		assert.ErrorIs(t, err, ErrIncompatibleUnits)
+	})
+}
+
+func TestMeasurementString(t *testing.T) {
+	assert.Equal(t, "5.51 km", Measurement{5.51, Kilometers}.String())
+	assert.Equal(t, "56%", Measurement{56, Percent}.String())
+	assert.Equal(t, "3", Measurement{3, Unitless}.String())
+}
+
+func TestMeasure(t *testing.T) {
+	session := loadFixture(t, "race.yaml")
+
+	t.Run("Weekend", func(t *testing.T) {
+		length, err := session.WeekendInfo.Length()
+		require.NoError(t, err)
+		assert.Equal(t, Measurement{4.74, Kilometers}, length)
+
+		airTemp, err := session.WeekendInfo.AirTemp()
+		require.NoError(t, err)
+
+		fahrenheit, err := airTemp.To(Fahrenheit)
+		require.NoError(t, err)
+		assert.InDelta(t, 78.0, fahrenheit.Value, 0.01)
+
+		pitSpeed, err := session.WeekendInfo.PitSpeedLimit()
+		require.NoError(t, err)
+		assert.Equal(t, Measurement{60, KPH}, pitSpeed)
+
+		for _, measure := range []func() (Measurement, error){
+			session.WeekendInfo.Altitude, session.WeekendInfo.SurfaceTemp, session.WeekendInfo.AirPressure,
+			session.WeekendInfo.WindSpeed, session.WeekendInfo.WindDirection, session.WeekendInfo.RelativeHumidity,
+		} {
+			_, err := measure()
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("Setup by path", func(t *testing.T) {
+		pressure, err := Measure(session, "CarSetup:TiresAero:LeftFront:StartingPressure")
+		require.NoError(t, err)
+		assert.Equal(t, Measurement{152, KPa}, pressure)
+
+		toeIn, err := Measure(session, "CarSetup:Chassis:Front:ToeIn")
+		require.NoError(t, err)
+		assert.Equal(t, Measurement{-0.0625, Inches}, toeIn)
+
+		temps, err := Lookup[string](session, "CarSetup:TiresAero:LeftFront:LastTempsOMI")
+		require.NoError(t, err)
+
+		omi, err := ParseMeasurements(temps)
+		require.NoError(t, err)
+		assert.Len(t, omi, 3)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := Measure(session, "CarSetup:Chassis:InCarDials:AbsSetting")
+		assert.ErrorIs(t, err, ErrBadMeasurement)
+		assert.EqualError(t, err, `CarSetup:Chassis:InCarDials:AbsSetting, err:can not parse measurement: "1 (ABS)", unknown unit "(ABS)"`)
+
+		_, err = Measure(session, "CarSetup:Chassis:Front:ArbBlades")
+		assert.ErrorIs(t, err, ErrWrongType)
+
+		_, err = Measure(session, "CarSetup:Chassis:Front:Unknown")
+		assert.ErrorIs(t, err, ErrPathNotFound)
+	})
+}
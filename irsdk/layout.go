@@ -0,0 +1,81 @@
+package irsdk
+
+import "encoding/binary"
+
+// Sizes of the memory map layout for MemHeader and VarHeader
+const (
+	MemHeaderSize = varBufsEnd    // the header and var buffer array, the var headers usually follow
+	VarHeaderSize = varHeaderSize // of each variable
+	MaxVarBufs    = maxBufs
+)
+
+// MemHeader of the memory map, written by Put as iRacing lays it out. It is the inverse of what the SDK reads, for
+// images of the memory map made without iRacing, e.g. test/memmap.
+type MemHeader struct {
+	Version           int
+	Connected         bool
+	TickRate          int
+	SessionInfoUpdate int
+	SessionInfoLen    int
+	SessionInfoOffset int
+	NumVars           int
+	VarHeaderOffset   int
+	BufLen            int
+	VarBufs           []VarBuffer // up to MaxVarBufs
+}
+
+// Put the header in the first MemHeaderSize bytes of buf
+func (mh *MemHeader) Put(buf []byte) {
+	put := func(off, v int) { binary.LittleEndian.PutUint32(buf[off:off+4], uint32(v)) }
+
+	status := 0
+	if mh.Connected {
+		status = stConnected
+	}
+
+	put(0, mh.Version)
+	put(4, status)
+	put(8, mh.TickRate)
+	put(12, mh.SessionInfoUpdate)
+	put(16, mh.SessionInfoLen)
+	put(20, mh.SessionInfoOffset)
+	put(24, mh.NumVars)
+	put(28, mh.VarHeaderOffset)
+	put(32, len(mh.VarBufs))
+	put(36, mh.BufLen)
+
+	clear(buf[headerSize:varBufsEnd])
+
+	for i, vb := range mh.VarBufs[:min(len(mh.VarBufs), maxBufs)] {
+		put(headerSize+i*varBufSize, vb.TickCount)
+		put(headerSize+i*varBufSize+4, vb.BufOffset)
+	}
+}
+
+// VarHeader describes a variable in the row, written by Put as iRacing lays it out
+type VarHeader struct {
+	Type        VarType
+	Offset      int // in the row
+	Count       int
+	CountAsTime bool
+	Name        string
+	Desc        string
+	Unit        string
+}
+
+// Put the header in the first VarHeaderSize bytes of buf
+func (vh *VarHeader) Put(buf []byte) {
+	clear(buf[:varHeaderSize])
+
+	binary.LittleEndian.PutUint32(buf[0:], uint32(vh.Type))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(vh.Offset))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(vh.Count))
+
+	if vh.CountAsTime {
+		buf[12] = 1
+	}
+
+	copy(buf[16:48], vh.Name)
+	copy(buf[48:112], vh.Desc)
+	copy(buf[112:144], vh.Unit)
+}
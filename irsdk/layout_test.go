@@ -0,0 +1,48 @@
+package irsdk
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutRoundTrip(t *testing.T) {
+	mh := MemHeader{
+		Version:           2,
+		Connected:         true,
+		TickRate:          60,
+		SessionInfoUpdate: 3,
+		SessionInfoLen:    100,
+		SessionInfoOffset: MemHeaderSize + VarHeaderSize,
+		NumVars:           1,
+		VarHeaderOffset:   MemHeaderSize,
+		BufLen:            8,
+		VarBufs:           []VarBuffer{{TickCount: 5, BufOffset: 1000}, {TickCount: 6, BufOffset: 1008}},
+	}
+
+	vh := VarHeader{Type: VarTypeDouble, Count: 1, CountAsTime: true, Name: "SessionTime", Desc: "Seconds since start", Unit: "s"}
+
+	img := make([]byte, MemHeaderSize+VarHeaderSize)
+	mh.Put(img)
+	vh.Put(img[MemHeaderSize:])
+
+	h := parseHeader(img)
+	assert.Equal(t, header{2, stConnected, 60, 3, 100, MemHeaderSize + VarHeaderSize, 1, MemHeaderSize, 2, 8}, h)
+
+	vb, err := findLatestBuffer(memoryMap{bytes.NewReader(img)}, &h)
+	require.NoError(t, err)
+	assert.Equal(t, VarBuffer{TickCount: 6, BufOffset: 1008}, vb)
+
+	vars, err := readVariableHeaders(memoryMap{bytes.NewReader(img)}, &h)
+	require.NoError(t, err)
+
+	v := vars.vars["SessionTime"]
+	assert.Equal(t, VarTypeDouble, v.VarType)
+	assert.Equal(t, 1, v.Count)
+	assert.True(t, v.countAsTime)
+	assert.Equal(t, "Seconds since start", v.Desc)
+	assert.Equal(t, "s", v.Unit)
+	assert.Equal(t, 8, VarTypeDouble.Size())
+}
@@ -0,0 +1,104 @@
+package irsdk
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// player paces playback of recorded samples, either one sample at a time or in real-time
+type player struct {
+	mux      sync.Mutex
+	samples  int
+	tickRate int
+	tick     func(sample int) int // tick number of a sample, must increase with the sample
+	now      func() time.Time
+	current  int
+	realTime bool
+	started  time.Time
+	startAt  int
+}
+
+func newPlayer(samples, tickRate int, tick func(sample int) int) player {
+	return player{
+		samples:  samples,
+		tickRate: tickRate,
+		tick:     tick,
+		now:      time.Now,
+	}
+}
+
+// NumSamples is the number of recorded samples
+func (p *player) NumSamples() int {
+	return p.samples
+}
+
+// Sample is the index of the sample currently presented to the SDK
+func (p *player) Sample() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	return p.position()
+}
+
+// Seek moves playback to the sample
+func (p *player) Seek(sample int) error {
+	if sample < 0 || sample >= p.samples {
+		return fmt.Errorf("sample %d out of range 0-%d", sample, p.samples-1)
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.current = sample
+	p.started = p.now()
+	p.startAt = sample
+
+	return nil
+}
+
+// Step advances playback by one sample, returning false at the end of the recording
+func (p *player) Step() bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	current := p.position()
+	if current+1 >= p.samples {
+		return false
+	}
+
+	p.current = current + 1
+	p.started = p.now()
+	p.startAt = p.current
+
+	return true
+}
+
+// RealTime when on advances playback with the wall clock at the recorded tick rate
+func (p *player) RealTime(on bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.current = p.position()
+	p.realTime = on
+	p.started = p.now()
+	p.startAt = p.current
+}
+
+// position must be called with the lock held
+func (p *player) position() int {
+	if !p.realTime || p.samples == 0 || p.tickRate <= 0 {
+		return p.current
+	}
+
+	elapsed := p.now().Sub(p.started)
+	target := p.tick(p.startAt) + int(elapsed*time.Duration(p.tickRate)/time.Second)
+
+	// first sample after the target tick, so the one before is current
+	next := sort.Search(p.samples, func(i int) bool { return p.tick(i) > target })
+
+	p.current = max(next-1, p.startAt)
+
+	return p.current
+}
@@ -0,0 +1,696 @@
+package irsdk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// The relay streams the memory map of the sim to RelayClients on other machines, e.g. the overlay on a streaming PC.
+//
+// The client sends a hello of magic | version, the server answers with its own hello, then sends records laid out
+// like a capture file
+//
+//	kind | tick | payload length | payload
+//
+// A start record of tickRate | numVars | bufLen | var headers is sent when iRacing connects, followed by session
+// records of the sessionInfoUpdate counter and raw YAML, and row records of the var buffer row for the tick.
+// An idle record says iRacing has disconnected.
+const (
+	relayMagic   = "IRSDKRLY"
+	relayVersion = 1
+
+	relayStart   byte = 1
+	relaySession byte = 2
+	relayRow     byte = 3
+	relayIdle    byte = 4
+
+	relayHelloSize        = len(relayMagic) + 4    //nolint:mnd // version
+	relayStartSize        = 12                     //nolint:mnd // 3 uint32
+	relayHandshakeTimeout = 5 * time.Second        // to exchange hellos
+	relayWriteTimeout     = 5 * time.Second        // before a stalled client is dropped
+	relayRetryMin         = 250 * time.Millisecond // between reconnects, doubling up to relayRetryMax
+	relayRetryMax         = 5 * time.Second
+	relayAcceptRetryMin   = 5 * time.Millisecond // between failed accepts, doubling up to relayRetryMax
+)
+
+type relayRecord struct {
+	kind    byte
+	tick    int
+	payload []byte
+}
+
+func writeHello(w io.Writer) error {
+	hello := make([]byte, relayHelloSize)
+	copy(hello, relayMagic)
+	binary.LittleEndian.PutUint32(hello[len(relayMagic):], relayVersion)
+
+	_, err := w.Write(hello)
+
+	return err
+}
+
+// readHello from the other end, ErrRelayVersion when it speaks another version of the protocol
+func readHello(r io.Reader) error {
+	hello := make([]byte, relayHelloSize)
+
+	_, err := io.ReadFull(r, hello)
+	if err != nil {
+		return fmt.Errorf("short relay hello, err:%w", err)
+	}
+
+	if string(hello[:len(relayMagic)]) != relayMagic {
+		return fmt.Errorf("not an irsdk relay")
+	}
+
+	if v := byte4ToInt(hello[len(relayMagic):]); v != relayVersion {
+		return fmt.Errorf("%w: got %d, want %d", ErrRelayVersion, v, relayVersion)
+	}
+
+	return nil
+}
+
+// RelayServer streams the memory map of an SDK to RelayClients, call Publish after each WaitForData
+type RelayServer struct {
+	sdk           *IRSDK
+	ln            net.Listener
+	mux           sync.Mutex
+	conns         map[net.Conn]*relayConn // nil until the handshake completes
+	start         relayRecord             // no payload while iRacing is not connected
+	session       relayRecord
+	row           relayRecord
+	numVars       int
+	bufLen        int
+	sessionUpdate int
+	clock         tickClock
+	done          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// NewRelayServer of the SDK's memory map to the clients connecting to ln
+func NewRelayServer(sdk *IRSDK, ln net.Listener) *RelayServer {
+	return &RelayServer{
+		sdk:   sdk,
+		ln:    ln,
+		conns: make(map[net.Conn]*relayConn),
+		done:  make(chan struct{}),
+	}
+}
+
+// Serve clients until Close, Accept errors are retried until the listener is closed
+func (rs *RelayServer) Serve() error {
+	retry := relayAcceptRetryMin
+
+	for {
+		conn, err := rs.ln.Accept()
+		if err != nil {
+			select {
+			case <-rs.done:
+				return nil
+			default:
+			}
+
+			if errors.Is(err, net.ErrClosed) {
+				return fmt.Errorf("relay accept, err:%w", err)
+			}
+
+			// e.g. out of file descriptors
+			log.Println("Relay accept failed, retrying in", retry, "err:", err)
+
+			select {
+			case <-rs.done:
+				return nil
+			case <-time.After(retry):
+			}
+
+			retry = min(retry*2, relayRetryMax)
+
+			continue
+		}
+
+		retry = relayAcceptRetryMin
+
+		rs.mux.Lock()
+
+		select {
+		case <-rs.done:
+			conn.Close()
+		default:
+			rs.conns[conn] = nil
+			rs.wg.Add(1)
+
+			go rs.serveConn(conn)
+		}
+
+		rs.mux.Unlock()
+	}
+}
+
+// Clients connected after the handshake
+func (rs *RelayServer) Clients() int {
+	rs.mux.Lock()
+	defer rs.mux.Unlock()
+
+	n := 0
+
+	for _, rc := range rs.conns {
+		if rc != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Publish the latest tick and any change to the session YAML to the clients, returning true if a new row was sent
+func (rs *RelayServer) Publish() (bool, error) {
+	rbuf := make([]byte, varBufsEnd)
+
+	err := readAt(rs.sdk.r, rbuf, 0, "header")
+	if err != nil {
+		return false, err
+	}
+
+	h := parseHeader(rbuf)
+
+	rs.mux.Lock()
+	defer rs.mux.Unlock()
+
+	if !sessionStatusOK(h.status) {
+		if rs.start.payload != nil {
+			rs.start, rs.session, rs.row = relayRecord{}, relayRecord{}, relayRecord{}
+			rs.broadcast(relayRecord{kind: relayIdle})
+		}
+
+		return false, nil
+	}
+
+	tick, bufOffset := latestRow(rbuf, &h)
+	tick = rs.clock.recorded(tick)
+
+	if rs.start.payload == nil || h.numVars != rs.numVars || h.bufLen != rs.bufLen {
+		err = rs.publishStart(&h, tick)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if h.sessionInfoUpdate != rs.sessionUpdate {
+		err = rs.publishSession(&h, tick)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if rs.row.payload != nil && tick <= rs.row.tick {
+		return false, nil
+	}
+
+	row := make([]byte, h.bufLen)
+
+	err = readAt(rs.sdk.r, row, int64(bufOffset), "var buffer row")
+	if err != nil {
+		return false, err
+	}
+
+	rs.row = relayRecord{kind: relayRow, tick: tick, payload: row}
+	rs.broadcast(rs.row)
+
+	return true, nil
+}
+
+// Close the listener and all the clients
+func (rs *RelayServer) Close() error {
+	rs.mux.Lock()
+
+	select {
+	case <-rs.done:
+		rs.mux.Unlock()
+		return nil
+	default:
+	}
+
+	close(rs.done)
+
+	for conn := range rs.conns {
+		conn.Close()
+	}
+
+	rs.mux.Unlock()
+
+	err := rs.ln.Close()
+
+	rs.wg.Wait()
+
+	return err
+}
+
+// publishStart must be called with the lock held
+func (rs *RelayServer) publishStart(h *header, tick int) error {
+	payload := make([]byte, relayStartSize+h.numVars*varHeaderSize)
+	binary.LittleEndian.PutUint32(payload[0:], uint32(h.tickRate))
+	binary.LittleEndian.PutUint32(payload[4:], uint32(h.numVars))
+	binary.LittleEndian.PutUint32(payload[8:], uint32(h.bufLen))
+
+	err := readAt(rs.sdk.r, payload[relayStartSize:], int64(h.headerOffset), "variable headers")
+	if err != nil {
+		return err
+	}
+
+	rs.start = relayRecord{kind: relayStart, tick: tick, payload: payload}
+	rs.session, rs.row = relayRecord{}, relayRecord{}
+	rs.numVars, rs.bufLen, rs.sessionUpdate = h.numVars, h.bufLen, -1
+
+	rs.broadcast(rs.start)
+
+	return nil
+}
+
+// publishSession must be called with the lock held
+func (rs *RelayServer) publishSession(h *header, tick int) error {
+	payload := make([]byte, 4+h.sessionInfoLen) //nolint:mnd // update counter
+	binary.LittleEndian.PutUint32(payload, uint32(h.sessionInfoUpdate))
+
+	err := readAt(rs.sdk.r, payload[4:], int64(h.sessionInfoOffset), "session")
+	if err != nil {
+		return err
+	}
+
+	rs.session = relayRecord{kind: relaySession, tick: tick, payload: bytes.TrimRight(payload, "\x00")}
+	rs.sessionUpdate = h.sessionInfoUpdate
+
+	rs.broadcast(rs.session)
+
+	return nil
+}
+
+// broadcast must be called with the lock held
+func (rs *RelayServer) broadcast(rec relayRecord) {
+	for _, rc := range rs.conns {
+		if rc != nil {
+			rc.queue(rec)
+		}
+	}
+}
+
+func (rs *RelayServer) serveConn(conn net.Conn) {
+	defer rs.wg.Done()
+
+	defer func() {
+		rs.mux.Lock()
+		delete(rs.conns, conn)
+		rs.mux.Unlock()
+
+		conn.Close()
+	}()
+
+	err := rs.handshake(conn)
+	if err != nil {
+		log.Println("Relay client", conn.RemoteAddr(), "rejected, err:", err)
+		return
+	}
+
+	rc := &relayConn{ready: make(chan struct{}, 1)}
+
+	rs.mux.Lock()
+
+	// new clients start from the latest state
+	if rs.start.payload == nil {
+		rc.queue(relayRecord{kind: relayIdle})
+	} else {
+		for _, rec := range []relayRecord{rs.start, rs.session, rs.row} {
+			if rec.payload != nil {
+				rc.queue(rec)
+			}
+		}
+	}
+
+	rs.conns[conn] = rc
+
+	rs.mux.Unlock()
+
+	w := bufio.NewWriter(conn)
+
+	for {
+		select {
+		case <-rs.done:
+			return
+		case <-rc.ready:
+		}
+
+		err = rc.send(conn, w)
+		if err != nil {
+			log.Println("Relay client", conn.RemoteAddr(), "dropped, err:", err)
+			return
+		}
+	}
+}
+
+func (rs *RelayServer) handshake(conn net.Conn) error {
+	err := conn.SetDeadline(time.Now().Add(relayHandshakeTimeout))
+	if err != nil {
+		return err
+	}
+
+	helloErr := readHello(conn)
+
+	// answer a mismatched client too so it can report both versions
+	err = writeHello(conn)
+	if helloErr != nil {
+		return helloErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return conn.SetDeadline(time.Time{})
+}
+
+// relayConn queues the records for a client. Only the latest row is kept, a slow client skips rows rather than
+// holding up the sim, but start, session and idle records are always sent.
+type relayConn struct {
+	mux     sync.Mutex
+	pending []relayRecord
+	row     *relayRecord
+	dropped int // rows replaced before they were sent
+	ready   chan struct{}
+}
+
+func (rc *relayConn) queue(rec relayRecord) {
+	rc.mux.Lock()
+
+	switch rec.kind {
+	case relayRow:
+		if rc.row != nil {
+			rc.dropped++
+		}
+
+		rc.row = &rec
+	case relayStart, relayIdle:
+		// anything queued is of the previous connection to iRacing
+		rc.pending = append(rc.pending[:0], rec)
+		rc.row = nil
+	default:
+		if n := len(rc.pending); n > 0 && rc.pending[n-1].kind == rec.kind {
+			rc.pending[n-1] = rec
+		} else {
+			rc.pending = append(rc.pending, rec)
+		}
+	}
+
+	rc.mux.Unlock()
+
+	select {
+	case rc.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take the queued records in the order they are sent
+func (rc *relayConn) take() []relayRecord {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	recs := rc.pending
+	rc.pending = nil
+
+	if rc.row != nil {
+		recs = append(recs, *rc.row)
+		rc.row = nil
+	}
+
+	return recs
+}
+
+func (rc *relayConn) send(conn net.Conn, w *bufio.Writer) error {
+	err := conn.SetWriteDeadline(time.Now().Add(relayWriteTimeout))
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range rc.take() {
+		err = writeRecord(w, rec.kind, rec.tick, rec.payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// RelayClient is the memory map of a RelayServer on another machine, pass it to Init in place of the shared memory.
+//
+// It reconnects until closed, meanwhile the SDK sees iRacing as not connected. A server that vanishes without
+// closing the connection is noticed by TCP keep-alive.
+type RelayClient struct {
+	addr    string
+	mux     sync.Mutex
+	image   memoryImage
+	started bool // a start record has been received
+	tick    int
+	session captureSessionInfo
+	row     []byte
+	err     error
+	pos     int64
+	data    chan struct{}
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// DialRelay connects to the relay server at addr in the background
+func DialRelay(addr string) *RelayClient {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	rc := &RelayClient{
+		addr:    addr,
+		data:    make(chan struct{}, 1),
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+
+	go rc.run(ctx)
+
+	return rc
+}
+
+// Connected when rows are being received from a connected sim
+func (rc *RelayClient) Connected() bool {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	return rc.started && rc.row != nil
+}
+
+// Err is why the connection to the relay last dropped, nil once reconnected
+func (rc *RelayClient) Err() error {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	return rc.err
+}
+
+// WaitForData returns true when a record has arrived, or the connection dropped, since the last wait
+func (rc *RelayClient) WaitForData(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-rc.data:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// ReadAt the memory map image of the latest row, all zeros while the relay or iRacing is not connected
+func (rc *RelayClient) ReadAt(p []byte, off int64) (int, error) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	return rc.readAt(p, off)
+}
+
+// Read sequentially through the memory map image
+func (rc *RelayClient) Read(p []byte) (int, error) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	n, err := rc.readAt(p, rc.pos)
+	rc.pos += int64(n)
+
+	return n, err
+}
+
+// readAt must be called with the lock held
+func (rc *RelayClient) readAt(p []byte, off int64) (int, error) {
+	if !rc.started || rc.row == nil {
+		clear(p)
+		return len(p), nil
+	}
+
+	head := rc.image.header(rc.tick, rc.session.update, len(rc.session.yaml))
+
+	return rc.image.readAt(p, off, head, rc.session.yaml, rc.row)
+}
+
+// Close the connection and stop reconnecting
+func (rc *RelayClient) Close() error {
+	rc.cancel()
+	<-rc.stopped
+
+	return nil
+}
+
+func (rc *RelayClient) run(ctx context.Context) {
+	defer close(rc.stopped)
+
+	retry := relayRetryMin
+
+	for {
+		connected, err := rc.receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		rc.disconnected(err)
+
+		if connected {
+			retry = relayRetryMin
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+
+		retry = min(retry*2, relayRetryMax)
+	}
+}
+
+// receive records until the connection drops, returning true if the handshake completed
+func (rc *RelayClient) receive(ctx context.Context) (bool, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", rc.addr)
+	if err != nil {
+		return false, err
+	}
+
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	err = conn.SetDeadline(time.Now().Add(relayHandshakeTimeout))
+	if err == nil {
+		err = writeHello(conn)
+	}
+
+	if err == nil {
+		err = readHello(conn)
+	}
+
+	if err == nil {
+		err = conn.SetDeadline(time.Time{})
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	rc.mux.Lock()
+	rc.err = nil
+	rc.mux.Unlock()
+
+	r := bufio.NewReader(conn)
+
+	for {
+		kind, tick, payload, err := readRecord(r)
+		if err != nil {
+			return true, fmt.Errorf("relay %w", err)
+		}
+
+		err = rc.apply(kind, tick, payload)
+		if err != nil {
+			return true, err
+		}
+	}
+}
+
+func (rc *RelayClient) apply(kind byte, tick int, payload []byte) error {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	switch kind {
+	case relayStart:
+		if len(payload) < relayStartSize {
+			return fmt.Errorf("corrupt relay start record")
+		}
+
+		image := memoryImage{
+			tickRate:   byte4ToInt(payload[0:4]),
+			numVars:    byte4ToInt(payload[4:8]),
+			bufLen:     byte4ToInt(payload[8:12]),
+			varHeaders: payload[relayStartSize:],
+		}
+
+		if len(image.varHeaders) != image.numVars*varHeaderSize {
+			return fmt.Errorf("corrupt relay start record, %d variables", image.numVars)
+		}
+
+		rc.image, rc.started, rc.session, rc.row = image, true, captureSessionInfo{}, nil
+	case relaySession:
+		if !rc.started || len(payload) < 4 { //nolint:mnd // update counter
+			return fmt.Errorf("corrupt relay session record")
+		}
+
+		rc.session = captureSessionInfo{update: byte4ToInt(payload[:4]), yaml: payload[4:]}
+		rc.image.sessionSpace = max(rc.image.sessionSpace, len(payload))
+	case relayRow:
+		if !rc.started || len(payload) != rc.image.bufLen {
+			return fmt.Errorf("corrupt relay row at tick %d", tick)
+		}
+
+		rc.tick, rc.row = tick, payload
+	case relayIdle:
+		rc.started, rc.row = false, nil
+	default:
+		return fmt.Errorf("unknown relay record %d", kind)
+	}
+
+	rc.signal()
+
+	return nil
+}
+
+// disconnected from the relay, logging each new reason
+func (rc *RelayClient) disconnected(err error) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	if rc.err == nil || rc.err.Error() != err.Error() {
+		log.Println("Relay", rc.addr, "disconnected, err:", err)
+	}
+
+	rc.started, rc.row, rc.err = false, nil, err
+
+	rc.signal()
+}
+
+// signal WaitForData, must be called with the lock held
+func (rc *RelayClient) signal() {
+	select {
+	case rc.data <- struct{}{}:
+	default:
+	}
+}
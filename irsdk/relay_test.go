@@ -0,0 +1,320 @@
+package irsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testOffline is a memory map that reads as iRacing not running while off
+type testOffline struct {
+	reader
+	off bool
+}
+
+func (o *testOffline) ReadAt(p []byte, off int64) (int, error) {
+	if o.off {
+		clear(p)
+		return len(p), nil
+	}
+
+	return o.reader.ReadAt(p, off)
+}
+
+func startTestRelay(t *testing.T, sdk *IRSDK, addr string) *RelayServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+
+	rs := NewRelayServer(sdk, ln)
+
+	go func() {
+		assert.NoError(t, rs.Serve())
+	}()
+
+	t.Cleanup(func() { rs.Close() })
+
+	return rs
+}
+
+// waitForRelay polls the SDK until the condition holds
+func waitForRelay(t *testing.T, sdk *IRSDK, condition func() bool) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		_, err := sdk.WaitForData(10 * time.Millisecond)
+		require.NoError(t, err)
+
+		return condition()
+	}, 5*time.Second, time.Millisecond)
+}
+
+func testSpeed(sdk *IRSDK) float32 {
+	speed, err := sdk.GetVarValue("Speed")
+	if err != nil {
+		return 0
+	}
+
+	return speed.(float32)
+}
+
+func TestRelay(t *testing.T) {
+	img := buildTestIbt(60, []float32{10.5, 20.5, 30.5}, []int32{1, 1, 2})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	sim := &testOffline{reader: ibt}
+	source := initTestSDK(t, sim)
+	rs := startTestRelay(t, source, "127.0.0.1:0")
+
+	ok, err := rs.Publish()
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = rs.Publish()
+	require.NoError(t, err)
+	assert.False(t, ok, "same tick")
+
+	rc := DialRelay(rs.ln.Addr().String())
+	defer rc.Close()
+
+	sdk, err := Init(rc)
+	require.NotNil(t, sdk)
+
+	t.Run("Latest state on connect", func(t *testing.T) {
+		waitForRelay(t, sdk, sdk.IsConnected)
+
+		assert.True(t, rc.Connected())
+		assert.NoError(t, rc.Err())
+		assert.Equal(t, 1, rs.Clients())
+		assert.Equal(t, 195, sdk.GetSession().WeekendInfo.TrackID)
+		assert.Equal(t, float32(10.5), testSpeed(sdk))
+	})
+
+	t.Run("Rows", func(t *testing.T) {
+		require.True(t, ibt.Step())
+
+		ok, err := rs.Publish()
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		waitForRelay(t, sdk, func() bool { return testSpeed(sdk) == 20.5 })
+	})
+
+	t.Run("iRacing disconnects", func(t *testing.T) {
+		sim.off = true
+
+		ok, err := rs.Publish()
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		waitForRelay(t, sdk, func() bool { return !sdk.IsConnected() })
+		assert.False(t, rc.Connected())
+		assert.Equal(t, 1, rs.Clients(), "still connected to the relay")
+
+		sim.off = false
+		require.True(t, ibt.Step())
+
+		ok, err = rs.Publish()
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		waitForRelay(t, sdk, func() bool { return testSpeed(sdk) == 30.5 })
+		assert.Equal(t, 195, sdk.GetSession().WeekendInfo.TrackID)
+	})
+}
+
+func TestRelayIracingRestart(t *testing.T) {
+	img := buildTestIbt(60, []float32{10.5, 20.5}, []int32{1, 1})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	rs := NewRelayServer(initTestSDK(t, ibt), nil)
+
+	require.True(t, ibt.Step())
+
+	ok, err := rs.Publish()
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// iRacing starts again from the first tick without the status dropping
+	require.NoError(t, ibt.Seek(0))
+
+	ok, err = rs.Publish()
+	require.NoError(t, err)
+	assert.True(t, ok, "rows carry on")
+	assert.Equal(t, 3, rs.row.tick)
+}
+
+func TestRelayReconnect(t *testing.T) {
+	img := buildTestIbt(60, []float32{10.5, 20.5}, []int32{1, 1})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	source := initTestSDK(t, ibt)
+	rs := startTestRelay(t, source, "127.0.0.1:0")
+	addr := rs.ln.Addr().String()
+
+	_, err = rs.Publish()
+	require.NoError(t, err)
+
+	rc := DialRelay(addr)
+	defer rc.Close()
+
+	sdk, _ := Init(rc)
+	waitForRelay(t, sdk, sdk.IsConnected)
+
+	require.NoError(t, rs.Close())
+
+	waitForRelay(t, sdk, func() bool { return !sdk.IsConnected() })
+	assert.Error(t, rc.Err())
+
+	require.True(t, ibt.Step())
+
+	rs = startTestRelay(t, source, addr)
+
+	_, err = rs.Publish()
+	require.NoError(t, err)
+
+	waitForRelay(t, sdk, func() bool { return testSpeed(sdk) == 20.5 })
+	assert.NoError(t, rc.Err())
+}
+
+// testFlakyListener fails the first Accept like a process out of file descriptors
+type testFlakyListener struct {
+	net.Listener
+	failed bool
+}
+
+func (l *testFlakyListener) Accept() (net.Conn, error) {
+	if !l.failed {
+		l.failed = true
+		return nil, &net.OpError{Op: "accept", Net: "tcp", Err: errors.New("too many open files")}
+	}
+
+	return l.Listener.Accept()
+}
+
+func TestRelayAcceptRetried(t *testing.T) {
+	img := buildTestIbt(60, []float32{10.5}, []int32{1})
+
+	ibt, err := NewIbtReader(bytes.NewReader(img), int64(len(img)))
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	rs := NewRelayServer(initTestSDK(t, ibt), &testFlakyListener{Listener: ln})
+
+	served := make(chan error, 1)
+
+	go func() { served <- rs.Serve() }()
+
+	_, err = rs.Publish()
+	require.NoError(t, err)
+
+	rc := DialRelay(ln.Addr().String())
+	defer rc.Close()
+
+	require.Eventually(t, rc.Connected, 5*time.Second, time.Millisecond, "served after the failed accept")
+
+	require.NoError(t, rs.Close())
+	assert.NoError(t, <-served)
+}
+
+func TestRelayVersion(t *testing.T) {
+	t.Run("Server speaks another version", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		defer ln.Close()
+
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			defer conn.Close()
+
+			hello := make([]byte, relayHelloSize)
+			_, _ = io.ReadFull(conn, hello)
+
+			copy(hello, relayMagic)
+			binary.LittleEndian.PutUint32(hello[len(relayMagic):], relayVersion+1)
+			_, _ = conn.Write(hello)
+		}()
+
+		rc := DialRelay(ln.Addr().String())
+		defer rc.Close()
+
+		require.Eventually(t, func() bool { return rc.Err() != nil }, 5*time.Second, time.Millisecond)
+		assert.ErrorIs(t, rc.Err(), ErrRelayVersion)
+		assert.False(t, rc.Connected())
+	})
+
+	t.Run("Client speaks another version", func(t *testing.T) {
+		rs := startTestRelay(t, nil, "127.0.0.1:0")
+
+		conn, err := net.Dial("tcp", rs.ln.Addr().String())
+		require.NoError(t, err)
+
+		defer conn.Close()
+
+		hello := make([]byte, relayHelloSize)
+		copy(hello, relayMagic)
+		binary.LittleEndian.PutUint32(hello[len(relayMagic):], relayVersion+1)
+
+		_, err = conn.Write(hello)
+		require.NoError(t, err)
+
+		require.NoError(t, readHello(conn), "server answers with its version")
+
+		_, err = conn.Read(hello)
+		assert.ErrorIs(t, err, io.EOF, "then hangs up")
+		assert.Equal(t, 0, rs.Clients())
+	})
+}
+
+func TestRelayConnQueue(t *testing.T) {
+	rc := relayConn{ready: make(chan struct{}, 1)}
+
+	rc.queue(relayRecord{kind: relayStart, tick: 1, payload: []byte{1}})
+	rc.queue(relayRecord{kind: relaySession, tick: 1, payload: []byte{1}})
+	rc.queue(relayRecord{kind: relaySession, tick: 2, payload: []byte{2}})
+
+	for tick := 1; tick <= 10; tick++ {
+		rc.queue(relayRecord{kind: relayRow, tick: tick})
+	}
+
+	recs := rc.take()
+	require.Len(t, recs, 3, "slow client only gets the latest session and row")
+	assert.Equal(t, relayStart, recs[0].kind)
+	assert.Equal(t, relaySession, recs[1].kind)
+	assert.Equal(t, []byte{2}, recs[1].payload)
+	assert.Equal(t, relayRow, recs[2].kind)
+	assert.Equal(t, 10, recs[2].tick)
+	assert.Equal(t, 9, rc.dropped)
+	assert.Len(t, rc.ready, 1)
+
+	assert.Empty(t, rc.take())
+
+	rc.queue(relayRecord{kind: relaySession, tick: 11})
+	rc.queue(relayRecord{kind: relayRow, tick: 11})
+	rc.queue(relayRecord{kind: relayIdle})
+
+	recs = rc.take()
+	require.Len(t, recs, 1, "records of the previous connection to iRacing are not sent")
+	assert.Equal(t, relayIdle, recs[0].kind)
+}
@@ -0,0 +1,63 @@
+package telemetry
+
+import "github.com/ianhaycox/ir-standings/irsdk"
+
+// Flag shown to the field, the most important of the SessionFlags
+type Flag string
+
+const (
+	NoFlag        Flag = ""
+	GreenFlag     Flag = "green"
+	YellowFlag    Flag = "yellow"  // local yellow
+	CautionFlag   Flag = "caution" // full course yellow
+	WhiteFlag     Flag = "white"   // final lap
+	CheckeredFlag Flag = "checkered"
+)
+
+// Unlimited time or laps remaining, iRacing reports a week or 32767 laps
+const (
+	Unlimited        = -1
+	unlimitedSeconds = 604800
+	unlimitedLaps    = 32767
+)
+
+// FlagOf the session flags, checkered before white before caution before yellow before green
+func FlagOf(flags irsdk.SessionFlags) Flag {
+	switch {
+	case flags&irsdk.FlagCheckered != 0:
+		return CheckeredFlag
+	case flags&irsdk.FlagWhite != 0:
+		return WhiteFlag
+	case flags&(irsdk.FlagCaution|irsdk.FlagCautionWaving) != 0:
+		return CautionFlag
+	case flags&(irsdk.FlagYellow|irsdk.FlagYellowWaving) != 0:
+		return YellowFlag
+	case flags&irsdk.FlagGreen != 0:
+		return GreenFlag
+	default:
+		return NoFlag
+	}
+}
+
+// FinalLap of a race once the white flag is out, the order is about to become final
+func (td *TelemetryData) FinalLap() bool {
+	return td.SessionType == "RACE" && td.Flag == WhiteFlag
+}
+
+// timeRemaining in seconds or Unlimited
+func timeRemaining(seconds float64) float64 {
+	if seconds < 0 || seconds >= unlimitedSeconds {
+		return Unlimited
+	}
+
+	return seconds
+}
+
+// lapsRemaining or Unlimited
+func lapsRemaining(laps int) int {
+	if laps < 0 || laps >= unlimitedLaps {
+		return Unlimited
+	}
+
+	return laps
+}
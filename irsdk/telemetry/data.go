@@ -1,8 +1,9 @@
 package telemetry
 
 import (
+	"errors"
 	"log"
-	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,12 +18,79 @@ const (
 type Data struct {
 	sdk  *irsdk.IRSDK
 	data *TelemetryData
+
+	// built from the session YAML, only when its sessionInfoUpdate changes
+	session       TelemetryData
+	sessionNames  []string
+	results       []sessionResults // ResultsPositions of each session
+	sessionUpdate int
+
+	// per session trackers, see resetTrackers
+	tracked   sessionKey
+	laps      lapHistory
+	retired   retirements
+	pits      pitStops
+	incidents incidentHistory
+	teams     teamHistory
+}
+
+// sessionKey of the session the trackers hold
+type sessionKey struct {
+	subsessionID int
+	sessionNum   int
+}
+
+// noSession is tracked until the first session, and again after iRacing disconnects
+var noSession = sessionKey{subsessionID: -1, sessionNum: -1}
+
+// telemetryVars are the only variables decoded each tick
+var telemetryVars = []string{
+	"SessionNum",
+	"SessionState",
+	"SessionFlags",
+	"SessionTimeRemain",
+	"SessionLapsRemainEx",
+	"CarIdxClassPosition",
+	"CarIdxLap",
+	"CarIdxTrackSurface",
+	"CarIdxLapDistPct",
+	"CarIdxLastLapTime",
+	"CarIdxBestLapTime",
+	"CarIdxEstTime",
+	"CarIdxOnPitRoad",
+	"SessionTime",
 }
 
 func NewData(sdk *irsdk.IRSDK) Data {
-	return Data{
-		sdk: sdk,
+	if sdk != nil {
+		sdk.Subscribe(telemetryVars...)
+	}
+
+	d := Data{
+		sdk:           sdk,
+		sessionUpdate: -1,
 	}
+
+	d.resetTrackers(noSession)
+
+	return d
+}
+
+// forSession clears the per session trackers when the session changes
+func (d *Data) forSession(key sessionKey) {
+	if key != d.tracked {
+		d.resetTrackers(key)
+	}
+}
+
+// resetTrackers for the session, every per session tracker is cleared here
+func (d *Data) resetTrackers(key sessionKey) {
+	d.tracked = key
+	d.laps = lapHistory{}
+	d.retired = retirements{}
+	d.pits = newPitStops()
+	d.incidents = incidentHistory{}
+	d.teams = teamHistory{}
 }
 
 func (d *Data) Telemetry() *TelemetryData {
@@ -30,11 +98,22 @@ func (d *Data) Telemetry() *TelemetryData {
 		Status: Waiting,
 	}
 
-	d.sdk.WaitForData(waitForDataMilli * time.Millisecond)
+	_, err := d.sdk.WaitForData(waitForDataMilli * time.Millisecond)
+	if err != nil && !errors.Is(err, irsdk.ErrNotConnected) {
+		log.Println("Telemetry problem:", err)
+
+		d.data.Status = Problem
+
+		return d.data
+	}
 
 	if !d.sdk.IsConnected() {
 		log.Println("iRacing disconnected")
 
+		// the update counter may repeat in the next iRacing session
+		d.sessionUpdate = -1
+		d.resetTrackers(noSession)
+
 		return d.data
 	}
 
@@ -42,11 +121,33 @@ func (d *Data) Telemetry() *TelemetryData {
 
 	d.updateSession()
 
-	sessionState, err := d.sdk.GetVarValue("SessionState")
+	sessionState, err := irsdk.Value[irsdk.SessionState](d.sdk, "SessionState")
+	if err != nil {
+		log.Println("Error getting SessionState:", err)
+	} else {
+		d.data.SessionState = sessionState
+	}
+
+	sessionFlags, err := irsdk.Value[irsdk.SessionFlags](d.sdk, "SessionFlags")
+	if err != nil {
+		log.Println("Error getting SessionFlags:", err)
+	} else {
+		d.data.SessionFlags = sessionFlags
+		d.data.Flag = FlagOf(sessionFlags)
+	}
+
+	sessionTimeRemain, err := irsdk.Value[float64](d.sdk, "SessionTimeRemain")
 	if err != nil {
-		log.Println("Error getting SessionState, %w", err)
+		log.Println("Error getting SessionTimeRemain:", err)
 	} else {
-		d.data.SessionState = sessionState.(int)
+		d.data.TimeRemaining = timeRemaining(sessionTimeRemain)
+	}
+
+	sessionLapsRemain, err := irsdk.Value[int](d.sdk, "SessionLapsRemainEx")
+	if err != nil {
+		log.Println("Error getting SessionLapsRemainEx:", err)
+	} else {
+		d.data.LapsRemaining = lapsRemaining(sessionLapsRemain)
 	}
 
 	d.updateCarInfo()
@@ -56,76 +157,195 @@ func (d *Data) Telemetry() *TelemetryData {
 
 // Updated often
 func (d *Data) updateCarInfo() {
-	cicp, err := d.sdk.GetVarValues("CarIdxClassPosition")
+	cicp, err := irsdk.Values[int](d.sdk, "CarIdxClassPosition")
 	if err != nil {
-		log.Println("Error getting CarIdxClassPosition, %w", err)
+		log.Println("Error getting CarIdxClassPosition:", err)
 	} else {
-		c := cicp.([]int)
-		for i := range c {
+		for i, c := range cicp {
 			if d.data.Cars[i].IsRacing() {
-				d.data.Cars[i].RacePositionInClass = c[i]
+				d.data.Cars[i].RacePositionInClass = c
 			} else {
 				d.data.Cars[i].RacePositionInClass = 0
 			}
 		}
 	}
 
-	cil, err := d.sdk.GetVarValues("CarIdxLap")
+	cil, err := irsdk.Values[int](d.sdk, "CarIdxLap")
 	if err != nil {
-		log.Println("Error getting CarIdxLap, %w", err)
+		log.Println("Error getting CarIdxLap:", err)
 	} else {
-		c := cil.([]int)
-		for i := range c {
+		for i, c := range cil {
 			if d.data.Cars[i].IsRacing() {
-				// I think byte4ToInt() should handle signed ints - bodge it here.
-				if c[i] > math.MaxInt32 {
-					d.data.Cars[i].LapsComplete = -1
-				} else {
-					d.data.Cars[i].LapsComplete = c[i]
-				}
+				d.data.Cars[i].LapsComplete = c
 			} else {
 				d.data.Cars[i].LapsComplete = 0
 			}
 		}
 	}
+
+	cits, err := irsdk.Values[irsdk.TrkLoc](d.sdk, "CarIdxTrackSurface")
+	if err != nil {
+		log.Println("Error getting CarIdxTrackSurface:", err)
+	} else {
+		for i, c := range cits {
+			if d.data.Cars[i].IsRacing() {
+				d.data.Cars[i].TrackSurface = c
+			} else {
+				d.data.Cars[i].TrackSurface = irsdk.TrkLocNotInWorld
+			}
+		}
+	}
+
+	ciopr, err := irsdk.Values[bool](d.sdk, "CarIdxOnPitRoad")
+	if err != nil {
+		log.Println("Error getting CarIdxOnPitRoad:", err)
+	}
+
+	d.retired.update(d.data, ciopr)
+	d.updateLapTimes()
+	d.updatePitStops(ciopr)
+	d.incidents.update(d.data)
+	d.teams.update(d.data)
+}
+
+// updatePitStops after the interpolated positions
+func (d *Data) updatePitStops(carIdxOnPitRoad []bool) {
+	sessionTime, err := irsdk.Value[float64](d.sdk, "SessionTime")
+	if err != nil {
+		log.Println("Error getting SessionTime:", err)
+	}
+
+	d.pits.update(d.data, carIdxOnPitRoad, sessionTime)
+}
+
+// updateLapTimes records each car's laps and works out the gaps and interpolated positions in class
+func (d *Data) updateLapTimes() {
+	cildp, err := irsdk.Values[float32](d.sdk, "CarIdxLapDistPct")
+	if err != nil {
+		log.Println("Error getting CarIdxLapDistPct:", err)
+	}
+
+	cillt, err := irsdk.Values[float32](d.sdk, "CarIdxLastLapTime")
+	if err != nil {
+		log.Println("Error getting CarIdxLastLapTime:", err)
+	}
+
+	cibl, err := irsdk.Values[float32](d.sdk, "CarIdxBestLapTime")
+	if err != nil {
+		log.Println("Error getting CarIdxBestLapTime:", err)
+	}
+
+	ciet, err := irsdk.Values[float32](d.sdk, "CarIdxEstTime")
+	if err != nil {
+		log.Println("Error getting CarIdxEstTime:", err)
+	}
+
+	for i := range d.data.Cars {
+		car := &d.data.Cars[i]
+
+		if !car.IsRacing() {
+			continue
+		}
+
+		if i < len(cildp) {
+			car.LapDistPct = cildp[i]
+		}
+
+		if i < len(cillt) {
+			d.laps.record(i, cillt[i])
+			car.LastLapTime = max(0, cillt[i])
+		}
+
+		car.BestLapTime = d.laps.best(i)
+		if i < len(cibl) && cibl[i] > 0 {
+			car.BestLapTime = cibl[i]
+		}
+
+		car.AverageLapTime = d.laps.average(i)
+	}
+
+	d.data.updateGaps(ciet)
+	d.data.updateInterpolatedPositions()
 }
 
 var (
 	cleanCRLF = strings.NewReplacer("\r", "", "\n", "")
 )
 
-// Updated rarely
+// Updated rarely, the driver table is only rebuilt when the session changes
 func (d *Data) updateSession() {
+	if update := d.sdk.GetSessionUpdate(); update != d.sessionUpdate {
+		if err := d.sdk.SessionError(); err != nil {
+			log.Println("Session problem, using the last good session:", err)
+		}
+
+		d.buildSession()
+		d.sessionUpdate = update
+	}
+
+	status := d.data.Status
+	*d.data = d.session
+	d.data.Status = status
+
+	sessionNum, err := irsdk.Value[int](d.sdk, "SessionNum")
+	if err != nil {
+		sessionNum = -1
+	}
+
+	if sessionNum >= 0 && sessionNum < len(d.sessionNames) {
+		d.data.SessionType = d.sessionNames[sessionNum]
+		d.data.Results = d.results[sessionNum].results
+		d.data.ResultsOfficial = d.results[sessionNum].official
+	}
+
+	d.forSession(sessionKey{subsessionID: d.data.SubsessionID, sessionNum: sessionNum})
+}
+
+func (d *Data) buildSession() {
 	session := d.sdk.GetSession()
 
-	d.data.SeriesID = session.WeekendInfo.SeriesID
-	d.data.SessionID = session.WeekendInfo.SessionID
-	d.data.SubsessionID = session.WeekendInfo.SubSessionID
+	d.session = TelemetryData{}
+
+	d.session.SeriesID = session.WeekendInfo.SeriesID
+	d.session.SessionID = session.WeekendInfo.SessionID
+	d.session.SubsessionID = session.WeekendInfo.SubSessionID
+
+	d.sessionNames = d.sessionNames[:0]
+	d.results = d.results[:0]
 
-	sessionNum, err := d.sdk.GetVar("SessionNum")
-	if err == nil {
-		d.data.SessionType = session.SessionInfo.Sessions[sessionNum.Value.(int)].SessionName
+	for i := range session.SessionInfo.Sessions {
+		d.sessionNames = append(d.sessionNames, session.SessionInfo.Sessions[i].SessionName)
+		d.results = append(d.results, newSessionResults(&session.SessionInfo.Sessions[i]))
 	}
 
-	d.data.TrackName = session.WeekendInfo.TrackDisplayName + " " + session.WeekendInfo.TrackConfigName
-	d.data.TrackID = session.WeekendInfo.TrackID
-	d.data.DriverCarIdx = session.DriverInfo.DriverCarIdx
+	d.session.TrackName = session.WeekendInfo.TrackDisplayName + " " + session.WeekendInfo.TrackConfigName
+	d.session.TrackID = session.WeekendInfo.TrackID
+	d.session.DriverCarIdx = session.DriverInfo.DriverCarIdx
+	d.session.TeamRacing = session.WeekendInfo.TeamRacing != 0
+
+	// "unlimited" or a count
+	d.session.IncidentLimit, _ = strconv.Atoi(session.WeekendInfo.WeekendOptions.IncidentLimit)
 
 	for i := range session.DriverInfo.Drivers {
 		carIdx := session.DriverInfo.Drivers[i].CarIdx
 
-		d.data.Cars[carIdx].CarClassID = session.DriverInfo.Drivers[i].CarClassID
-		d.data.Cars[carIdx].CarID = session.DriverInfo.Drivers[i].CarID
-		d.data.Cars[carIdx].CarNumber = session.DriverInfo.Drivers[i].CarNumber
-		d.data.Cars[carIdx].CustID = session.DriverInfo.Drivers[i].UserID
-		d.data.Cars[carIdx].DriverName = cleanCRLF.Replace(session.DriverInfo.Drivers[i].UserName)
-		d.data.Cars[carIdx].IRating = session.DriverInfo.Drivers[i].IRating
-		d.data.Cars[carIdx].IsPaceCar = session.DriverInfo.Drivers[i].CarIsPaceCar == 1
-		d.data.Cars[carIdx].IsSelf = carIdx == d.data.DriverCarIdx
-		d.data.Cars[carIdx].IsSpectator = session.DriverInfo.Drivers[i].IsSpectator == 1
-
-		if d.data.Cars[carIdx].IsSelf {
-			d.data.SelfCarClassID = session.DriverInfo.Drivers[i].CarClassID
+		d.session.Cars[carIdx].CarClassID = session.DriverInfo.Drivers[i].CarClassID
+		d.session.Cars[carIdx].CarID = session.DriverInfo.Drivers[i].CarID
+		d.session.Cars[carIdx].CarNumber = session.DriverInfo.Drivers[i].CarNumber
+		d.session.Cars[carIdx].CustID = session.DriverInfo.Drivers[i].UserID
+		d.session.Cars[carIdx].DriverName = cleanCRLF.Replace(session.DriverInfo.Drivers[i].UserName)
+		d.session.Cars[carIdx].IRating = session.DriverInfo.Drivers[i].IRating
+		d.session.Cars[carIdx].IsPaceCar = session.DriverInfo.Drivers[i].CarIsPaceCar == 1
+		d.session.Cars[carIdx].IsSelf = carIdx == d.session.DriverCarIdx
+		d.session.Cars[carIdx].IsSpectator = session.DriverInfo.Drivers[i].IsSpectator == 1
+		d.session.Cars[carIdx].Incidents = session.DriverInfo.Drivers[i].CurDriverIncidentCount
+		d.session.Cars[carIdx].TeamIncidents = session.DriverInfo.Drivers[i].TeamIncidentCount
+		d.session.Cars[carIdx].TeamID = session.DriverInfo.Drivers[i].TeamID
+		d.session.Cars[carIdx].TeamName = cleanCRLF.Replace(session.DriverInfo.Drivers[i].TeamName)
+		d.session.Cars[carIdx].classEstLapTime = session.DriverInfo.Drivers[i].CarClassEstLapTime
+
+		if d.session.Cars[carIdx].IsSelf {
+			d.session.SelfCarClassID = session.DriverInfo.Drivers[i].CarClassID
 		}
 	}
 }
@@ -0,0 +1,51 @@
+package telemetry
+
+import (
+	"fmt"
+	"slices"
+)
+
+// IncidentDelta of a car's incident count, e.g. +4x on lap 12
+type IncidentDelta struct {
+	Lap   int `json:"lap"`
+	Count int `json:"count"` // added on the lap
+	Total int `json:"total"` // after the incident
+}
+
+func (d IncidentDelta) String() string {
+	return fmt.Sprintf("+%dx on lap %d", d.Count, d.Lap)
+}
+
+// incidentHistory of each car in the current session from TeamIncidentCount, which carries across driver swaps.
+// The count when a car is first seen is the baseline, when it was added is unknown.
+type incidentHistory struct {
+	seen   [IrMaxCars]bool
+	total  [IrMaxCars]int
+	deltas [IrMaxCars][]IncidentDelta
+}
+
+// update the deltas from the counts in the session
+func (ih *incidentHistory) update(td *TelemetryData) {
+	for i := range td.Cars {
+		car := &td.Cars[i]
+
+		if !car.IsRacing() {
+			continue
+		}
+
+		switch {
+		case !ih.seen[i]:
+			ih.seen[i] = true
+			ih.total[i] = car.TeamIncidents
+		case car.TeamIncidents > ih.total[i]:
+			ih.deltas[i] = append(ih.deltas[i], IncidentDelta{
+				Lap:   car.LapsComplete,
+				Count: car.TeamIncidents - ih.total[i],
+				Total: car.TeamIncidents,
+			})
+			ih.total[i] = car.TeamIncidents
+		}
+
+		car.IncidentDeltas = slices.Clone(ih.deltas[i])
+	}
+}
@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"sort"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+)
+
+// lapHistory of each car in the current session, a lap is recorded whenever CarIdxLastLapTime changes
+type lapHistory struct {
+	lastLapTime [IrMaxCars]float32
+	times       [IrMaxCars][]float32
+}
+
+// record a lap when the last lap time changes, -1 means no lap yet. Two laps with the same time in a row count once.
+func (lh *lapHistory) record(carIdx int, lastLapTime float32) {
+	if lastLapTime == lh.lastLapTime[carIdx] {
+		return
+	}
+
+	lh.lastLapTime[carIdx] = lastLapTime
+
+	if lastLapTime > 0 {
+		lh.times[carIdx] = append(lh.times[carIdx], lastLapTime)
+	}
+}
+
+// average lap time of the car in this session, 0 without a lap
+func (lh *lapHistory) average(carIdx int) float32 {
+	if len(lh.times[carIdx]) == 0 {
+		return 0
+	}
+
+	var total float32
+
+	for _, lapTime := range lh.times[carIdx] {
+		total += lapTime
+	}
+
+	return total / float32(len(lh.times[carIdx]))
+}
+
+// best lap time of the car in this session, 0 without a lap
+func (lh *lapHistory) best(carIdx int) float32 {
+	var best float32
+
+	for _, lapTime := range lh.times[carIdx] {
+		if best == 0 || lapTime < best {
+			best = lapTime
+		}
+	}
+
+	return best
+}
+
+// updateGaps of each car to its class leader and the car ahead in class, by class position.
+// Gaps are seconds, a lap down adds a reference lap of the car behind, its best or the class estimate.
+func (td *TelemetryData) updateGaps(estTime []float32) {
+	byClass := make(map[int][]int)
+
+	for i := range td.Cars {
+		td.Cars[i].GapToClassLeader = 0
+		td.Cars[i].IntervalToCarAhead = 0
+
+		if !td.Cars[i].IsRacing() || td.Cars[i].RacePositionInClass <= 0 || td.Cars[i].TrackSurface == irsdk.TrkLocNotInWorld {
+			continue
+		}
+
+		byClass[td.Cars[i].CarClassID] = append(byClass[td.Cars[i].CarClassID], i)
+	}
+
+	for _, carIdxs := range byClass {
+		sort.Slice(carIdxs, func(i, j int) bool {
+			return td.Cars[carIdxs[i]].RacePositionInClass < td.Cars[carIdxs[j]].RacePositionInClass
+		})
+
+		leader := carIdxs[0]
+
+		for n := 1; n < len(carIdxs); n++ {
+			behind := carIdxs[n]
+
+			td.Cars[behind].GapToClassLeader = td.gap(leader, behind, estTime)
+			td.Cars[behind].IntervalToCarAhead = td.gap(carIdxs[n-1], behind, estTime)
+		}
+	}
+}
+
+// gap in seconds between the car ahead and the car behind
+func (td *TelemetryData) gap(ahead, behind int, estTime []float32) float32 {
+	if ahead >= len(estTime) || behind >= len(estTime) {
+		return 0
+	}
+
+	referenceLap := td.Cars[behind].BestLapTime
+	if referenceLap <= 0 {
+		referenceLap = td.Cars[behind].classEstLapTime
+	}
+
+	lapsAhead := float32(td.Cars[ahead].LapsComplete - td.Cars[behind].LapsComplete)
+
+	return max(0, lapsAhead*referenceLap+estTime[ahead]-estTime[behind])
+}
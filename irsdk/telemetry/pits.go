@@ -0,0 +1,112 @@
+package telemetry
+
+import (
+	"slices"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+)
+
+// PitStop of a car from pit lane entry to exit
+type PitStop struct {
+	CarIdx          int     `json:"car_idx"`
+	CustID          int     `json:"cust_id"`
+	CarNumber       string  `json:"car_number"`
+	DriverName      string  `json:"driver_name"`
+	EntryLap        int     `json:"entry_lap"`
+	ExitLap         int     `json:"exit_lap"`
+	Exited          bool    `json:"exited"`            // false while the car is still in the pit lane
+	EntryTime       float64 `json:"entry_time"`        // session seconds
+	TimeInPitLane   float64 `json:"time_in_pit_lane"`  // seconds
+	PositionAtEntry int     `json:"position_at_entry"` // interpolated class position on track
+	PositionAtExit  int     `json:"position_at_exit"`
+	PositionsLost   int     `json:"positions_lost"` // in class, negative when places were gained
+}
+
+// pitStops of every car in the current session in the order the cars entered the pit lane
+type pitStops struct {
+	onPitRoad [IrMaxCars]bool
+	position  [IrMaxCars]int // interpolated class position on the previous tick
+	open      [IrMaxCars]int // index of the stop in log while the car is in the pit lane, else -1
+	log       []PitStop
+}
+
+func newPitStops() pitStops {
+	var p pitStops
+
+	for i := range p.open {
+		p.open[i] = -1
+	}
+
+	return p
+}
+
+// update the stops from CarIdxOnPitRoad or the track surface, positions are interpolated so they are known on exit
+func (p *pitStops) update(td *TelemetryData, carIdxOnPitRoad []bool, sessionTime float64) {
+	for i := range td.Cars {
+		car := &td.Cars[i]
+
+		if !car.IsRacing() {
+			p.onPitRoad[i] = false
+			p.open[i] = -1
+
+			continue
+		}
+
+		onPitRoad := isOnPitRoad(car, i, carIdxOnPitRoad)
+
+		switch {
+		case onPitRoad && !p.onPitRoad[i]:
+			p.open[i] = len(p.log)
+			p.log = append(p.log, PitStop{
+				CarIdx:          i,
+				CustID:          car.CustID,
+				CarNumber:       car.CarNumber,
+				DriverName:      car.DriverName,
+				EntryLap:        car.LapsComplete,
+				EntryTime:       sessionTime,
+				PositionAtEntry: p.position[i],
+			})
+
+		case !onPitRoad && p.onPitRoad[i] && p.open[i] >= 0 && car.TrackSurface != irsdk.TrkLocNotInWorld:
+			stop := &p.log[p.open[i]]
+			stop.ExitLap = car.LapsComplete
+			stop.Exited = true
+			stop.TimeInPitLane = sessionTime - stop.EntryTime
+			stop.PositionAtExit = car.InterpolatedPositionInClass
+
+			if stop.PositionAtEntry > 0 && stop.PositionAtExit > 0 {
+				stop.PositionsLost = stop.PositionAtExit - stop.PositionAtEntry
+			}
+
+			p.open[i] = -1
+		}
+
+		p.onPitRoad[i] = onPitRoad
+		p.position[i] = car.InterpolatedPositionInClass
+
+		car.PitStopCount = 0
+
+		var last *PitStop
+
+		for n := range p.log {
+			if p.log[n].CarIdx == i && p.log[n].CustID == car.CustID {
+				car.PitStopCount++
+				last = &p.log[n]
+			}
+		}
+
+		// positions are only right again once the car has crossed the line after its stop
+		car.Pitting = onPitRoad || (last != nil && last.Exited && last.ExitLap == car.LapsComplete)
+	}
+
+	td.PitStops = slices.Clone(p.log)
+}
+
+// isOnPitRoad from CarIdxOnPitRoad or the track surface
+func isOnPitRoad(car *CarInfo, carIdx int, carIdxOnPitRoad []bool) bool {
+	if car.TrackSurface == irsdk.TrkLocInPitStall || car.TrackSurface == irsdk.TrkLocAproachingPits {
+		return true
+	}
+
+	return carIdx < len(carIdxOnPitRoad) && carIdxOnPitRoad[carIdx]
+}
@@ -0,0 +1,115 @@
+package telemetry
+
+import (
+	"sort"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+)
+
+// updateInterpolatedPositions ranks the cars in each class by laps plus distance around the lap, so a pass counts
+// the moment it happens rather than at the start/finish line. Cars in the pit lane or not in the world hold their
+// official class position, their lap distance jumps where the pit lane bypasses the track or is -1.
+func (td *TelemetryData) updateInterpolatedPositions() {
+	byClass := make(map[int][]int)
+
+	for i := range td.Cars {
+		td.Cars[i].InterpolatedPositionInClass = 0
+
+		if !td.Cars[i].IsRacing() {
+			continue
+		}
+
+		byClass[td.Cars[i].CarClassID] = append(byClass[td.Cars[i].CarClassID], i)
+	}
+
+	for _, carIdxs := range byClass {
+		held := make([]int, 0, len(carIdxs))
+		moving := make([]int, 0, len(carIdxs))
+
+		for _, carIdx := range carIdxs {
+			if td.Cars[carIdx].holdsPosition() {
+				held = append(held, carIdx)
+			} else {
+				moving = append(moving, carIdx)
+			}
+		}
+
+		sort.SliceStable(held, func(i, j int) bool {
+			return td.Cars[held[i]].officialOrder() < td.Cars[held[j]].officialOrder()
+		})
+
+		sort.SliceStable(moving, func(i, j int) bool {
+			a, b := &td.Cars[moving[i]], &td.Cars[moving[j]]
+			if a.progress() != b.progress() {
+				return a.progress() > b.progress()
+			}
+
+			return a.officialOrder() < b.officialOrder()
+		})
+
+		slots := make([]int, len(carIdxs))
+		for i := range slots {
+			slots[i] = -1
+		}
+
+		for _, carIdx := range held {
+			slots[freeSlot(slots, td.Cars[carIdx].RacePositionInClass-1)] = carIdx
+		}
+
+		next := 0
+
+		for i := range slots {
+			if slots[i] == -1 {
+				slots[i] = moving[next]
+				next++
+			}
+
+			td.Cars[slots[i]].InterpolatedPositionInClass = i + 1
+		}
+	}
+}
+
+// freeSlot at or after want, else the last free one before it
+func freeSlot(slots []int, want int) int {
+	if want < 0 || want >= len(slots) {
+		want = len(slots) - 1
+	}
+
+	for i := want; i < len(slots); i++ {
+		if slots[i] == -1 {
+			return i
+		}
+	}
+
+	for i := want - 1; i >= 0; i-- {
+		if slots[i] == -1 {
+			return i
+		}
+	}
+
+	return want
+}
+
+// holdsPosition when the lap distance can not be trusted
+func (ci *CarInfo) holdsPosition() bool {
+	switch ci.TrackSurface {
+	case irsdk.TrkLocNotInWorld, irsdk.TrkLocInPitStall, irsdk.TrkLocAproachingPits:
+		return true
+	}
+
+	return ci.LapDistPct < 0
+}
+
+// progress in laps around the track
+func (ci *CarInfo) progress() float32 {
+	return float32(ci.LapsComplete) + ci.LapDistPct
+}
+
+// officialOrder by class position, unclassified cars last
+func (ci *CarInfo) officialOrder() int {
+	if ci.RacePositionInClass <= 0 {
+		return IrMaxCars + 1
+	}
+
+	return ci.RacePositionInClass
+}
@@ -0,0 +1,305 @@
+// Package raceevents publishes what happened in a race by comparing successive telemetry snapshots
+package raceevents
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+	"github.com/ianhaycox/ir-standings/irsdk/telemetry"
+)
+
+// Event is one of the types below, switch on the type to handle it
+type Event interface {
+	fmt.Stringer
+}
+
+// SessionTypeChanged e.g. from PRACTICE to QUALIFY to RACE
+type SessionTypeChanged struct {
+	From string
+	To   string
+}
+
+// FlagShown is FlagGreen, FlagCaution or FlagCheckered
+type FlagShown struct {
+	Flag irsdk.SessionFlags
+}
+
+// DriverJoined the session
+type DriverJoined struct {
+	CarIdx int
+	Car    telemetry.CarInfo
+}
+
+// DriverLeft the session
+type DriverLeft struct {
+	CarIdx int
+	Car    telemetry.CarInfo
+}
+
+// ClassLeadChanged from one car to another, From is -1 if there was no leader
+type ClassLeadChanged struct {
+	CarClassID int
+	From       int
+	To         int
+	Leader     telemetry.CarInfo
+}
+
+// CrossedLine starting Lap
+type CrossedLine struct {
+	CarIdx int
+	Car    telemetry.CarInfo
+	Lap    int
+}
+
+// Retired from the race, telemetry.CarInfo Retired was set on the car, Lap is the last lap it completed
+type Retired struct {
+	CarIdx int
+	Car    telemetry.CarInfo
+	Lap    int
+}
+
+// PitEntered the pit lane
+type PitEntered struct {
+	Stop telemetry.PitStop
+}
+
+// PitExited the pit lane back on track
+type PitExited struct {
+	Stop telemetry.PitStop
+}
+
+func (e SessionTypeChanged) String() string {
+	return fmt.Sprintf("session changed from %q to %q", e.From, e.To)
+}
+
+func (e FlagShown) String() string {
+	return fmt.Sprintf("%s flag", e.Flag)
+}
+
+func (e DriverJoined) String() string {
+	return fmt.Sprintf("#%s %s joined", e.Car.CarNumber, e.Car.DriverName)
+}
+
+func (e DriverLeft) String() string {
+	return fmt.Sprintf("#%s %s left", e.Car.CarNumber, e.Car.DriverName)
+}
+
+func (e ClassLeadChanged) String() string {
+	return fmt.Sprintf("#%s %s leads class %d", e.Leader.CarNumber, e.Leader.DriverName, e.CarClassID)
+}
+
+func (e CrossedLine) String() string {
+	return fmt.Sprintf("#%s %s started lap %d", e.Car.CarNumber, e.Car.DriverName, e.Lap)
+}
+
+func (e Retired) String() string {
+	return fmt.Sprintf("#%s %s retired on lap %d", e.Car.CarNumber, e.Car.DriverName, e.Lap)
+}
+
+func (e PitEntered) String() string {
+	return fmt.Sprintf("#%s %s entered the pits on lap %d", e.Stop.CarNumber, e.Stop.DriverName, e.Stop.EntryLap)
+}
+
+func (e PitExited) String() string {
+	return fmt.Sprintf("#%s %s left the pits on lap %d after %.1fs, %+d places", e.Stop.CarNumber, e.Stop.DriverName,
+		e.Stop.ExitLap, e.Stop.TimeInPitLane, -e.Stop.PositionsLost)
+}
+
+// Detector remembers the previous snapshot to compare the next one with
+type Detector struct {
+	prev *telemetry.TelemetryData
+}
+
+// Diff returns the events between the previous snapshot and next, the first connected snapshot is the baseline
+func (d *Detector) Diff(next *telemetry.TelemetryData) []Event {
+	if next == nil || next.Status != telemetry.Connected {
+		d.prev = nil
+		return nil
+	}
+
+	prev := d.prev
+	snapshot := *next
+	d.prev = &snapshot
+
+	if prev == nil {
+		return nil
+	}
+
+	if prev.SubsessionID != next.SubsessionID {
+		// a different iRacing session, nothing to compare with
+		return nil
+	}
+
+	var events []Event
+
+	if prev.SessionType != next.SessionType {
+		events = append(events, SessionTypeChanged{From: prev.SessionType, To: next.SessionType})
+	}
+
+	events = append(events, flags(prev.SessionFlags, next.SessionFlags)...)
+	events = append(events, drivers(prev, next)...)
+
+	if prev.SessionType == next.SessionType {
+		// laps and positions restart with each session
+		events = append(events, classLeaders(prev, next)...)
+		events = append(events, laps(prev, next)...)
+		events = append(events, pitStops(prev, next)...)
+	}
+
+	return events
+}
+
+func flags(prev, next irsdk.SessionFlags) []Event {
+	var events []Event
+
+	for _, flag := range []irsdk.SessionFlags{irsdk.FlagGreen, irsdk.FlagCaution, irsdk.FlagCheckered} {
+		mask := flag
+		if flag == irsdk.FlagCaution {
+			mask |= irsdk.FlagCautionWaving
+		}
+
+		if prev&mask == 0 && next&mask != 0 {
+			events = append(events, FlagShown{Flag: flag})
+		}
+	}
+
+	return events
+}
+
+func drivers(prev, next *telemetry.TelemetryData) []Event {
+	var events []Event
+
+	// a driver who vanishes mid-race is kept in the car as it was last seen, marked Left
+	inSession := func(car *telemetry.CarInfo) bool { return car.IsRacing() && !car.Left }
+
+	for carIdx := range next.Cars {
+		was, is := prev.Cars[carIdx], next.Cars[carIdx]
+
+		if inSession(&was) && (!inSession(&is) || was.CustID != is.CustID) {
+			events = append(events, DriverLeft{CarIdx: carIdx, Car: was})
+		}
+
+		if inSession(&is) && (!inSession(&was) || was.CustID != is.CustID) {
+			events = append(events, DriverJoined{CarIdx: carIdx, Car: is})
+		}
+	}
+
+	return events
+}
+
+func classLeaders(prev, next *telemetry.TelemetryData) []Event {
+	var events []Event
+
+	was := leaders(prev)
+
+	for carIdx := range next.Cars {
+		leader := next.Cars[carIdx]
+		if !leader.IsRacing() || leader.RacePositionInClass != 1 {
+			continue
+		}
+
+		from, ok := was[leader.CarClassID]
+		if !ok {
+			from = -1
+		}
+
+		if from != carIdx {
+			events = append(events, ClassLeadChanged{CarClassID: leader.CarClassID, From: from, To: carIdx, Leader: leader})
+		}
+	}
+
+	return events
+}
+
+// leaders car index by car class
+func leaders(td *telemetry.TelemetryData) map[int]int {
+	result := make(map[int]int)
+
+	for carIdx := range td.Cars {
+		if td.Cars[carIdx].IsRacing() && td.Cars[carIdx].RacePositionInClass == 1 {
+			result[td.Cars[carIdx].CarClassID] = carIdx
+		}
+	}
+
+	return result
+}
+
+func laps(prev, next *telemetry.TelemetryData) []Event {
+	var events []Event
+
+	for carIdx := range next.Cars {
+		was, is := prev.Cars[carIdx], next.Cars[carIdx]
+
+		if !was.IsRacing() || !is.IsRacing() || was.CustID != is.CustID {
+			continue
+		}
+
+		if is.LapsComplete > was.LapsComplete && was.LapsComplete >= 0 {
+			events = append(events, CrossedLine{CarIdx: carIdx, Car: is, Lap: is.LapsComplete})
+		}
+
+		if !was.Retired && is.Retired {
+			events = append(events, Retired{CarIdx: carIdx, Car: is, Lap: is.LapsComplete})
+		}
+	}
+
+	return events
+}
+
+// pitStops entered or exited, the stops of a session are only ever appended to
+func pitStops(prev, next *telemetry.TelemetryData) []Event {
+	var events []Event
+
+	for n, stop := range next.PitStops {
+		if n >= len(prev.PitStops) {
+			events = append(events, PitEntered{Stop: stop})
+		}
+
+		if stop.Exited && (n >= len(prev.PitStops) || !prev.PitStops[n].Exited) {
+			events = append(events, PitExited{Stop: stop})
+		}
+	}
+
+	return events
+}
+
+// Source of telemetry snapshots, e.g. telemetry.Data
+type Source interface {
+	Telemetry() *telemetry.TelemetryData
+}
+
+// Watch polls the source every interval and publishes the events on the returned channel, closed when ctx is done
+func Watch(ctx context.Context, source Source, interval time.Duration) <-chan Event {
+	const buffer = 64
+
+	events := make(chan Event, buffer)
+
+	go func() {
+		defer close(events)
+
+		var detector Detector
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			for _, event := range detector.Diff(source.Telemetry()) {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
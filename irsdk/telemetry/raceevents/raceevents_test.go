@@ -0,0 +1,278 @@
+package raceevents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+	"github.com/ianhaycox/ir-standings/irsdk/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	gtp = 84
+	gto = 83
+)
+
+func racingSnapshot() *telemetry.TelemetryData {
+	td := &telemetry.TelemetryData{
+		SubsessionID: 1,
+		SessionType:  "RACE",
+		SessionState: irsdk.SessionStateRacing,
+		SessionFlags: irsdk.FlagGreen,
+		Status:       telemetry.Connected,
+	}
+
+	for carIdx := range td.Cars {
+		td.Cars[carIdx].TrackSurface = irsdk.TrkLocNotInWorld
+	}
+
+	td.Cars[0] = telemetry.CarInfo{DriverName: "Pace Car", IsPaceCar: true, TrackSurface: irsdk.TrkLocOnTrack}
+	td.Cars[1] = telemetry.CarInfo{CustID: 100, CarNumber: "1", DriverName: "Alice", CarClassID: gtp, RacePositionInClass: 1, LapsComplete: 3, TrackSurface: irsdk.TrkLocOnTrack}
+	td.Cars[2] = telemetry.CarInfo{CustID: 200, CarNumber: "2", DriverName: "Bob", CarClassID: gtp, RacePositionInClass: 2, LapsComplete: 3, TrackSurface: irsdk.TrkLocOnTrack}
+	td.Cars[3] = telemetry.CarInfo{CustID: 300, CarNumber: "3", DriverName: "Carol", CarClassID: gto, RacePositionInClass: 1, LapsComplete: 2, TrackSurface: irsdk.TrkLocOnTrack}
+
+	return td
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("First snapshot is the baseline", func(t *testing.T) {
+		var d Detector
+
+		assert.Empty(t, d.Diff(racingSnapshot()))
+		assert.Empty(t, d.Diff(racingSnapshot()))
+	})
+
+	t.Run("Disconnected resets the baseline", func(t *testing.T) {
+		var d Detector
+
+		d.Diff(racingSnapshot())
+		assert.Empty(t, d.Diff(&telemetry.TelemetryData{Status: telemetry.Waiting}))
+
+		next := racingSnapshot()
+		next.Cars[1].LapsComplete++
+		assert.Empty(t, d.Diff(next))
+	})
+
+	t.Run("Session type change", func(t *testing.T) {
+		var d Detector
+
+		prev := racingSnapshot()
+		prev.SessionType = "QUALIFY"
+		prev.SessionFlags = 0
+		d.Diff(prev)
+
+		next := racingSnapshot()
+		next.Cars[1].LapsComplete = 0
+
+		assert.Equal(t, []Event{
+			SessionTypeChanged{From: "QUALIFY", To: "RACE"},
+			FlagShown{Flag: irsdk.FlagGreen},
+		}, d.Diff(next))
+	})
+
+	t.Run("Flags", func(t *testing.T) {
+		var d Detector
+
+		d.Diff(racingSnapshot())
+
+		next := racingSnapshot()
+		next.SessionFlags = irsdk.FlagCautionWaving
+		assert.Equal(t, []Event{FlagShown{Flag: irsdk.FlagCaution}}, d.Diff(next))
+
+		next = racingSnapshot()
+		next.SessionFlags = irsdk.FlagCaution
+		assert.Empty(t, d.Diff(next))
+
+		next = racingSnapshot()
+		next.SessionFlags = irsdk.FlagCheckered
+		assert.Equal(t, []Event{FlagShown{Flag: irsdk.FlagCheckered}}, d.Diff(next))
+	})
+
+	t.Run("Drivers joining and leaving", func(t *testing.T) {
+		var d Detector
+
+		d.Diff(racingSnapshot())
+
+		next := racingSnapshot()
+		next.Cars[4] = telemetry.CarInfo{CustID: 400, CarNumber: "4", DriverName: "Dave", CarClassID: gto}
+		next.Cars[2] = telemetry.CarInfo{}
+
+		assert.Equal(t, []Event{
+			DriverLeft{CarIdx: 2, Car: racingSnapshot().Cars[2]},
+			DriverJoined{CarIdx: 4, Car: next.Cars[4]},
+		}, d.Diff(next))
+	})
+
+	t.Run("Class lead change", func(t *testing.T) {
+		var d Detector
+
+		d.Diff(racingSnapshot())
+
+		next := racingSnapshot()
+		next.Cars[1].RacePositionInClass = 2
+		next.Cars[2].RacePositionInClass = 1
+
+		assert.Equal(t, []Event{ClassLeadChanged{CarClassID: gtp, From: 1, To: 2, Leader: next.Cars[2]}}, d.Diff(next))
+	})
+
+	t.Run("Crossing the line", func(t *testing.T) {
+		var d Detector
+
+		d.Diff(racingSnapshot())
+
+		next := racingSnapshot()
+		next.Cars[3].LapsComplete++
+
+		events := d.Diff(next)
+		assert.Equal(t, []Event{CrossedLine{CarIdx: 3, Car: next.Cars[3], Lap: 3}}, events)
+		assert.Equal(t, "#3 Carol started lap 3", events[0].String())
+	})
+
+	t.Run("Retiring", func(t *testing.T) {
+		var d Detector
+
+		d.Diff(racingSnapshot())
+
+		next := racingSnapshot()
+		next.Cars[2].TrackSurface = irsdk.TrkLocNotInWorld
+		next.Cars[2].Retired = true
+
+		assert.Equal(t, []Event{Retired{CarIdx: 2, Car: next.Cars[2], Lap: 3}}, d.Diff(next))
+
+		next = racingSnapshot()
+		next.Cars[2].Retired = true
+		assert.Empty(t, d.Diff(next), "towed back, still retired")
+	})
+
+	t.Run("Stalled on track", func(t *testing.T) {
+		var d Detector
+
+		d.Diff(racingSnapshot())
+
+		next := racingSnapshot()
+		next.Cars[3].Retired = true
+
+		assert.Equal(t, []Event{Retired{CarIdx: 3, Car: next.Cars[3], Lap: 2}}, d.Diff(next))
+	})
+
+	t.Run("Vanished from the session", func(t *testing.T) {
+		var d Detector
+
+		d.Diff(racingSnapshot())
+
+		next := racingSnapshot()
+		next.Cars[2].TrackSurface = irsdk.TrkLocNotInWorld
+		next.Cars[2].Retired = true
+		next.Cars[2].Left = true
+
+		assert.Equal(t, []Event{
+			DriverLeft{CarIdx: 2, Car: racingSnapshot().Cars[2]},
+			Retired{CarIdx: 2, Car: next.Cars[2], Lap: 3},
+		}, d.Diff(next))
+
+		assert.Empty(t, d.Diff(next), "kept as last seen")
+
+		back := racingSnapshot()
+		back.Cars[2].Retired = true
+		assert.Equal(t, []Event{DriverJoined{CarIdx: 2, Car: back.Cars[2]}}, d.Diff(back), "rejoined")
+	})
+
+	t.Run("Pit stops", func(t *testing.T) {
+		var d Detector
+
+		d.Diff(racingSnapshot())
+
+		in := telemetry.PitStop{CarIdx: 2, CustID: 200, CarNumber: "2", DriverName: "Bob", EntryLap: 3, EntryTime: 300, PositionAtEntry: 1}
+
+		next := racingSnapshot()
+		next.PitStops = []telemetry.PitStop{in}
+
+		events := d.Diff(next)
+		assert.Equal(t, []Event{PitEntered{Stop: in}}, events)
+		assert.Equal(t, "#2 Bob entered the pits on lap 3", events[0].String())
+
+		out := in
+		out.ExitLap, out.Exited, out.TimeInPitLane, out.PositionAtExit, out.PositionsLost = 4, true, 32.25, 2, 1
+
+		quick := telemetry.PitStop{CarIdx: 1, CustID: 100, CarNumber: "1", DriverName: "Alice", EntryLap: 4, ExitLap: 4, Exited: true, TimeInPitLane: 20}
+
+		next = racingSnapshot()
+		next.PitStops = []telemetry.PitStop{out, quick}
+
+		events = d.Diff(next)
+		assert.Equal(t, []Event{PitExited{Stop: out}, PitEntered{Stop: quick}, PitExited{Stop: quick}}, events)
+		assert.Equal(t, "#2 Bob left the pits on lap 4 after 32.2s, -1 places", events[0].String())
+
+		assert.Empty(t, d.Diff(next))
+	})
+
+	t.Run("Pit lane start", func(t *testing.T) {
+		var d Detector
+
+		start := telemetry.PitStop{CarIdx: 2, CustID: 200, CarNumber: "2", DriverName: "Bob"}
+
+		prev := racingSnapshot()
+		prev.PitStops = []telemetry.PitStop{start}
+		d.Diff(prev)
+
+		start.Exited, start.TimeInPitLane = true, 12
+
+		next := racingSnapshot()
+		next.PitStops = []telemetry.PitStop{start}
+
+		assert.Equal(t, []Event{PitExited{Stop: start}}, d.Diff(next), "left the pits on lap 0")
+	})
+
+	t.Run("Leaving the world after the race is not retiring", func(t *testing.T) {
+		var d Detector
+
+		prev := racingSnapshot()
+		prev.SessionState = irsdk.SessionStateCheckered
+		prev.SessionFlags = irsdk.FlagCheckered
+		d.Diff(prev)
+
+		next := racingSnapshot()
+		next.SessionState = irsdk.SessionStateCheckered
+		next.SessionFlags = irsdk.FlagCheckered
+		next.Cars[2].TrackSurface = irsdk.TrkLocNotInWorld
+
+		assert.Empty(t, d.Diff(next))
+	})
+}
+
+type fakeSource struct {
+	snapshots []*telemetry.TelemetryData
+}
+
+func (f *fakeSource) Telemetry() *telemetry.TelemetryData {
+	td := f.snapshots[0]
+
+	if len(f.snapshots) > 1 {
+		f.snapshots = f.snapshots[1:]
+	}
+
+	return td
+}
+
+func TestWatch(t *testing.T) {
+	next := racingSnapshot()
+	next.Cars[1].LapsComplete++
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events := Watch(ctx, &fakeSource{snapshots: []*telemetry.TelemetryData{racingSnapshot(), next}}, time.Millisecond)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, CrossedLine{CarIdx: 1, Car: next.Cars[1], Lap: 4}, event)
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+
+	cancel()
+
+	for range events { //nolint:revive // drain until closed
+	}
+}
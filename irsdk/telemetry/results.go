@@ -0,0 +1,46 @@
+package telemetry
+
+import (
+	"github.com/ianhaycox/ir-standings/irsdk"
+	"github.com/ianhaycox/ir-standings/irsdk/iryaml"
+)
+
+// ReasonOutRunning is the ReasonOutStr of a car still running at the end of the session
+const ReasonOutRunning = "Running"
+
+// SessionResult of a car from ResultsPositions in the session YAML, iRacing's own classification
+type SessionResult struct {
+	CarIdx        int    `json:"car_idx"`
+	Position      int    `json:"position"`
+	ClassPosition int    `json:"class_position"` // from 0 like the API's finish_position_in_class
+	LapsComplete  int    `json:"laps_complete"`
+	ReasonOut     string `json:"reason_out"` // Running, Disconnected etc.
+}
+
+// sessionResults of one session in the YAML
+type sessionResults struct {
+	results  []SessionResult
+	official bool
+}
+
+func newSessionResults(session *iryaml.Session) sessionResults {
+	sr := sessionResults{official: session.ResultsOfficial != 0}
+
+	for _, rp := range session.ResultsPositions {
+		sr.results = append(sr.results, SessionResult{
+			CarIdx:        rp.CarIdx,
+			Position:      rp.Position,
+			ClassPosition: rp.ClassPosition,
+			LapsComplete:  rp.LapsComplete,
+			ReasonOut:     rp.ReasonOutStr,
+		})
+	}
+
+	return sr
+}
+
+// Checkered once the leader has taken the checkered flag
+func (td *TelemetryData) Checkered() bool {
+	return td.SessionState == irsdk.SessionStateCheckered || td.SessionState == irsdk.SessionStateCoolDown ||
+		td.SessionFlags.Has(irsdk.FlagCheckered)
+}
@@ -0,0 +1,79 @@
+package telemetry
+
+import "github.com/ianhaycox/ir-standings/irsdk"
+
+// stalledLeaderLaps the class leader completes while a car's lap count stays the same before the car is retired
+const stalledLeaderLaps = 2
+
+// retirements of cars during a race. A car retires when it leaves the world, stops completing laps on track or its
+// driver vanishes from the session, and stays retired if it is towed back. Its laps are frozen at the last lap it
+// completed while running, as are the laps of a car that leaves the world after finishing.
+type retirements struct {
+	laps       [IrMaxCars]int     // highest lap count while running
+	leaderLaps [IrMaxCars]int     // class leader's laps when the car last gained a lap or was on pit road
+	retired    [IrMaxCars]bool    // latched once detected while racing
+	known      [IrMaxCars]CarInfo // last seen while racing, for drivers that vanish from the session
+}
+
+// update the cars of a race, new retirements are only detected while racing so that cars leaving the world after
+// the checkered flag still finish
+func (r *retirements) update(td *TelemetryData, carIdxOnPitRoad []bool) {
+	if td.SessionType != "RACE" {
+		return
+	}
+
+	racing := td.SessionState == irsdk.SessionStateRacing
+
+	// a faster class covers the laps of a slower class's long stop
+	leaderLaps := make(map[int]int)
+
+	for i := range td.Cars {
+		car := &td.Cars[i]
+		if car.IsRacing() && car.LapsComplete > leaderLaps[car.CarClassID] {
+			leaderLaps[car.CarClassID] = car.LapsComplete
+		}
+	}
+
+	for i := range td.Cars {
+		car := &td.Cars[i]
+
+		if !car.IsRacing() {
+			if r.known[i].IsRacing() {
+				// vanished from DriverInfo
+				*car = r.known[i]
+				car.TrackSurface = irsdk.TrkLocNotInWorld
+				car.Left = true
+				car.Retired = racing || r.retired[i]
+				r.retired[i] = car.Retired
+			}
+
+			continue
+		}
+
+		classLeaderLaps := leaderLaps[car.CarClassID]
+
+		switch {
+		case r.retired[i]:
+			// laps after a tow do not count
+		case !r.known[i].IsRacing() || car.LapsComplete > r.laps[i]:
+			// the stall is measured from when the car is first seen
+			r.laps[i] = car.LapsComplete
+			r.leaderLaps[i] = classLeaderLaps
+		case isOnPitRoad(car, i, carIdxOnPitRoad):
+			// repairs take as long as they take
+			r.leaderLaps[i] = classLeaderLaps
+		}
+
+		if racing && !r.retired[i] {
+			r.retired[i] = car.TrackSurface == irsdk.TrkLocNotInWorld || classLeaderLaps-r.leaderLaps[i] >= stalledLeaderLaps
+		}
+
+		car.Retired = r.retired[i]
+		if car.Retired || car.TrackSurface == irsdk.TrkLocNotInWorld {
+			// iRacing reports -1 laps once a car leaves the world, finished or not
+			car.LapsComplete = r.laps[i]
+		}
+
+		r.known[i] = *car
+	}
+}
@@ -0,0 +1,44 @@
+package telemetry
+
+import "slices"
+
+// TeamDriver who has driven a car this session
+type TeamDriver struct {
+	CustID     int    `json:"cust_id"`
+	DriverName string `json:"driver_name"`
+	IRating    int    `json:"irating"`
+}
+
+// teamHistory of the drivers of each car in the current session. In team events the UserID in DriverInfo is
+// whoever is driving now, the TeamID identifies the entry.
+type teamHistory struct {
+	entry   [IrMaxCars]int // TeamID, or -CustID outside team events
+	drivers [IrMaxCars][]TeamDriver
+}
+
+// update the drivers of each car in the order they first drove it, a different entry in the car starts again
+func (th *teamHistory) update(td *TelemetryData) {
+	for i := range td.Cars {
+		car := &td.Cars[i]
+
+		if !car.IsRacing() {
+			continue
+		}
+
+		entry := car.TeamID
+		if entry == 0 {
+			entry = -car.CustID
+		}
+
+		if th.entry[i] != entry {
+			th.entry[i] = entry
+			th.drivers[i] = nil
+		}
+
+		if !slices.ContainsFunc(th.drivers[i], func(d TeamDriver) bool { return d.CustID == car.CustID }) {
+			th.drivers[i] = append(th.drivers[i], TeamDriver{CustID: car.CustID, DriverName: car.DriverName, IRating: car.IRating})
+		}
+
+		car.Drivers = slices.Clone(th.drivers[i])
+	}
+}
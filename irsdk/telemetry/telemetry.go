@@ -1,6 +1,8 @@
 // Package telemetry read from Windows shared memory
 package telemetry
 
+import "github.com/ianhaycox/ir-standings/irsdk"
+
 const (
 	IrMaxCars    = 64
 	Connected    = "Connected"
@@ -12,31 +14,61 @@ const (
 type CarsInfo [IrMaxCars]CarInfo
 
 type CarInfo struct {
-	CarClassID          int    `json:"car_class_id"`
-	CarID               int    `json:"car_id"`
-	CarNumber           string `json:"car_number"`
-	CustID              int    `json:"cust_id"`
-	DriverName          string `json:"driver_name"`
-	IRating             int    `json:"irating"`
-	IsPaceCar           bool   `json:"is_pace_car"`
-	IsSelf              bool   `json:"is_self"`
-	IsSpectator         bool   `json:"is_spectator"`
-	LapsComplete        int    `json:"laps_complete"`
-	RacePositionInClass int    `json:"race_position_in_class"`
+	CarClassID                  int             `json:"car_class_id"`
+	CarID                       int             `json:"car_id"`
+	CarNumber                   string          `json:"car_number"`
+	CustID                      int             `json:"cust_id"`
+	DriverName                  string          `json:"driver_name"`
+	IRating                     int             `json:"irating"`
+	IsPaceCar                   bool            `json:"is_pace_car"`
+	IsSelf                      bool            `json:"is_self"`
+	IsSpectator                 bool            `json:"is_spectator"`
+	LapsComplete                int             `json:"laps_complete"`
+	RacePositionInClass         int             `json:"race_position_in_class"`
+	TrackSurface                irsdk.TrkLoc    `json:"track_surface"` // NotInWorld, OnTrack, InPitStall etc.
+	LapDistPct                  float32         `json:"lap_dist_pct"`  // 0 to 1 around the lap
+	LastLapTime                 float32         `json:"last_lap_time"` // seconds, 0 without a lap
+	BestLapTime                 float32         `json:"best_lap_time"`
+	AverageLapTime              float32         `json:"average_lap_time"` // of the laps this session
+	GapToClassLeader            float32         `json:"gap_to_class_leader"`
+	IntervalToCarAhead          float32         `json:"interval_to_car_ahead"`          // in class
+	InterpolatedPositionInClass int             `json:"interpolated_position_in_class"` // by laps and lap distance, not only at the line
+	Retired                     bool            `json:"retired"`                        // DNF, LapsComplete frozen when it retired
+	Left                        bool            `json:"left"`                           // vanished from the session, kept as last seen
+	Pitting                     bool            `json:"pitting"`                        // in the pit lane or not yet back to the line
+	PitStopCount                int             `json:"pit_stop_count"`
+	Incidents                   int             `json:"incidents"`      // of the current driver
+	TeamIncidents               int             `json:"team_incidents"` // of the car, counted towards the incident limit
+	IncidentDeltas              []IncidentDelta `json:"incident_deltas,omitempty"`
+	TeamID                      int             `json:"team_id"` // the entry in team events, else 0
+	TeamName                    string          `json:"team_name"`
+	Drivers                     []TeamDriver    `json:"drivers,omitempty"` // of the car this session, the current driver is CustID
+
+	classEstLapTime float32 // from the session, for gaps before a car sets a lap
 }
 
 type TelemetryData struct {
-	SeriesID       int      `json:"series_id"`
-	SessionID      int      `json:"session_id"`
-	SubsessionID   int      `json:"subsession_id"`
-	SessionType    string   `json:"session_type"`  // PRACTICE, QUALIFY, RACE
-	SessionState   int      `json:"session_state"` // Warmup, Racing, Cooldown etc.
-	Status         string   `json:"status"`        // Connected, Driving
-	TrackName      string   `json:"track_name"`
-	TrackID        int      `json:"track_id"`
-	DriverCarIdx   int      `json:"driver_car_idx"`
-	SelfCarClassID int      `json:"self_car_class_id"`
-	Cars           CarsInfo `json:"cars,omitempty"`
+	SeriesID       int                `json:"series_id"`
+	SessionID      int                `json:"session_id"`
+	SubsessionID   int                `json:"subsession_id"`
+	SessionType    string             `json:"session_type"`   // PRACTICE, QUALIFY, RACE
+	SessionState   irsdk.SessionState `json:"session_state"`  // Warmup, Racing, Cooldown etc.
+	SessionFlags   irsdk.SessionFlags `json:"session_flags"`  // Green, Caution, Checkered etc.
+	Flag           Flag               `json:"flag"`           // the most important of the SessionFlags
+	TimeRemaining  float64            `json:"time_remaining"` // seconds, Unlimited in a lap race
+	LapsRemaining  int                `json:"laps_remaining"` // for the leader, Unlimited in a timed race
+	Status         string             `json:"status"`         // Connected, Driving
+	TrackName      string             `json:"track_name"`
+	TrackID        int                `json:"track_id"`
+	DriverCarIdx   int                `json:"driver_car_idx"`
+	SelfCarClassID int                `json:"self_car_class_id"`
+	IncidentLimit  int                `json:"incident_limit"` // disqualification, 0 if unlimited
+	TeamRacing     bool               `json:"team_racing"`    // cars are entered by teams and swap drivers
+	Cars           CarsInfo           `json:"cars,omitempty"`
+	PitStops       []PitStop          `json:"pit_stops,omitempty"` // this session in order of pit entry
+
+	Results         []SessionResult `json:"results,omitempty"` // of this session from the YAML, as cars finish
+	ResultsOfficial bool            `json:"results_official"`
 }
 
 func (td *TelemetryData) SofByCarClass() map[int]int {
@@ -2,29 +2,41 @@ package telemetry
 
 import (
 	"fmt"
+	"io"
 	"log"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/ianhaycox/ir-standings/irsdk"
+	"github.com/ianhaycox/ir-standings/irsdk/iryaml"
+	"github.com/ianhaycox/ir-standings/test/memmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTelemetry(t *testing.T) {
 	t.Skip()
 
-	reader, err := os.Open("/tmp/test.ibt")
+	reader, err := irsdk.OpenIbt("/tmp/test.ibt")
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	reader.RealTime(true)
+
 	log.Println("Init irSDK Linux(other)")
 
-	sdk := irsdk.Init(reader)
+	sdk, err := irsdk.Init(reader)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	online := true
 	for {
-		sdk.WaitForData(100 * time.Millisecond)
+		_, err = sdk.WaitForData(100 * time.Millisecond)
+		if err != nil {
+			t.Fail()
+		}
 
 		speed, err := sdk.GetVar("Speed")
 		if err != nil {
@@ -52,7 +64,10 @@ func TestTelemetry(t *testing.T) {
 func TestTelemetry2(t *testing.T) {
 	t.Skip()
 
-	sdk := irsdk.Init(nil)
+	sdk, err := irsdk.Init(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	x := NewData(sdk)
 
@@ -60,3 +75,701 @@ func TestTelemetry2(t *testing.T) {
 
 	fmt.Println(s)
 }
+
+// brokenReader fails every read like a torn memory map
+type brokenReader struct{}
+
+func (brokenReader) Read(_ []byte) (int, error)            { return 0, io.ErrUnexpectedEOF }
+func (brokenReader) ReadAt(_ []byte, _ int64) (int, error) { return 0, io.ErrUnexpectedEOF }
+func (brokenReader) Close() error                          { return nil }
+
+func TestTelemetryProblem(t *testing.T) {
+	sdk, err := irsdk.Init(brokenReader{})
+	assert.ErrorIs(t, err, irsdk.ErrShortRead)
+
+	d := NewData(sdk)
+
+	assert.Equal(t, Problem, d.Telemetry().Status)
+}
+
+// newTestData connects telemetry to the race scripted by b
+func newTestData(t *testing.T, b *memmap.Builder) (*memmap.Image, *Data) {
+	t.Helper()
+
+	img, sdk := b.Connect(t)
+	d := NewData(sdk)
+
+	return img, &d
+}
+
+func TestTelemetryData(t *testing.T) {
+	drivers := []iryaml.Driver{
+		{CarIdx: 1, UserName: "Alice", UserID: 100, CarNumber: "1", CarClassID: 84, IRating: 3000},
+		{CarIdx: 2, UserName: "Bob", UserID: 200, CarNumber: "2", CarClassID: 84, IRating: 2000},
+	}
+
+	race := memmap.NewRace(memmap.RaceSession(285, 1000, drivers...)).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"SessionFlags":        irsdk.FlagGreen | irsdk.FlagStartGo,
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{0: 7, 1: 3, 2: -1}),
+			"CarIdxTrackSurface":  memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocInPitStall}),
+		}).
+		Tick(memmap.Values{})
+
+	img, d := newTestData(t, race)
+
+	td := d.Telemetry()
+
+	assert.Equal(t, Connected, td.Status)
+	assert.Equal(t, "RACE", td.SessionType)
+	assert.Equal(t, irsdk.SessionStateRacing, td.SessionState)
+	assert.True(t, td.SessionFlags.Has(irsdk.FlagGreen))
+	assert.Equal(t, 1000, td.SubsessionID)
+	assert.Equal(t, "Alice", td.Cars[1].DriverName)
+	assert.Equal(t, 3, td.Cars[1].LapsComplete)
+	assert.Equal(t, -1, td.Cars[2].LapsComplete)
+	assert.Equal(t, 0, td.Cars[0].LapsComplete, "pace car")
+	assert.Equal(t, irsdk.TrkLocInPitStall, td.Cars[2].TrackSurface)
+	assert.Equal(t, irsdk.TrkLocNotInWorld, td.Cars[3].TrackSurface)
+	assert.Equal(t, 2500, td.SofByCarClass()[84])
+
+	t.Run("Driver table is rebuilt when the session changes", func(t *testing.T) {
+		drivers[1].UserName = "Bobby"
+		require.NoError(t, img.SetSession(memmap.RaceSession(285, 1000, drivers...)))
+		require.True(t, img.Next())
+
+		td := d.Telemetry()
+
+		assert.Equal(t, "Bobby", td.Cars[2].DriverName)
+		assert.Equal(t, 2, td.Cars[2].RacePositionInClass)
+	})
+
+	t.Run("Snapshots are independent", func(t *testing.T) {
+		td.Cars[1].DriverName = "changed"
+
+		assert.Equal(t, "Alice", d.Telemetry().Cars[1].DriverName)
+	})
+}
+
+func TestTelemetryLapTimes(t *testing.T) {
+	const (
+		gtp = 84
+		gto = 83
+	)
+
+	race := memmap.NewRace(memmap.RaceSession(285, 1000,
+		iryaml.Driver{CarIdx: 1, UserName: "Leader", UserID: 100, CarClassID: gtp, CarClassEstLapTime: 105},
+		iryaml.Driver{CarIdx: 2, UserName: "Second", UserID: 200, CarClassID: gtp, CarClassEstLapTime: 105},
+		iryaml.Driver{CarIdx: 3, UserName: "Lapped", UserID: 300, CarClassID: gtp, CarClassEstLapTime: 105},
+		iryaml.Driver{CarIdx: 4, UserName: "Other class", UserID: 400, CarClassID: gto, CarClassEstLapTime: 110},
+		iryaml.Driver{CarIdx: 5, UserName: "No laps", UserID: 500, CarClassID: gto, CarClassEstLapTime: 110},
+	)).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2, 3: 3, 4: 1, 5: 2}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{1: 3, 2: 3, 3: 2, 4: 3, 5: 2}),
+			"CarIdxTrackSurface":  memmap.PerCar(map[int]irsdk.TrkLoc{1: 3, 2: 3, 3: 3, 4: 3, 5: 3}),
+			"CarIdxLapDistPct":    memmap.PerCar(map[int]float32{1: 0.5, 2: 0.48, 3: 0.6}),
+			"CarIdxLastLapTime":   memmap.PerCar(map[int]float32{1: 100, 2: 101, 3: 102, 4: 108, 5: -1}),
+			"CarIdxBestLapTime":   memmap.PerCar(map[int]float32{1: 100, 2: 101, 3: 102, 4: 108, 5: -1}),
+			"CarIdxEstTime":       memmap.PerCar(map[int]float32{1: 50, 2: 48, 3: 60, 4: 20, 5: 30}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap":         memmap.PerCar(map[int]int{1: 4, 2: 3, 3: 2, 4: 3, 5: 2}),
+			"CarIdxLastLapTime": memmap.PerCar(map[int]float32{1: 98, 2: 101, 3: 102, 4: 108, 5: -1}),
+			"CarIdxBestLapTime": memmap.PerCar(map[int]float32{1: 98, 2: 101, 3: 102, 4: 108, 5: -1}),
+			"CarIdxEstTime":     memmap.PerCar(map[int]float32{1: 1, 2: 99, 3: 60, 4: 20, 5: 30}),
+		})
+
+	img, d := newTestData(t, race)
+
+	td := d.Telemetry()
+
+	assert.InDelta(t, 0.48, td.Cars[2].LapDistPct, 1e-6)
+	assert.Equal(t, float32(101), td.Cars[2].LastLapTime)
+	assert.Equal(t, float32(101), td.Cars[2].BestLapTime)
+	assert.Equal(t, float32(101), td.Cars[2].AverageLapTime)
+
+	assert.Zero(t, td.Cars[1].GapToClassLeader, "leader")
+	assert.Zero(t, td.Cars[1].IntervalToCarAhead, "leader")
+	assert.InDelta(t, 2, td.Cars[2].GapToClassLeader, 1e-3)
+	assert.InDelta(t, 2, td.Cars[2].IntervalToCarAhead, 1e-3)
+	assert.InDelta(t, 102+50-60, td.Cars[3].GapToClassLeader, 1e-3, "a lap down")
+	assert.InDelta(t, 102+48-60, td.Cars[3].IntervalToCarAhead, 1e-3)
+
+	assert.Zero(t, td.Cars[4].GapToClassLeader, "leader of the other class")
+	assert.Zero(t, td.Cars[5].LastLapTime, "no lap yet")
+	assert.Zero(t, td.Cars[5].AverageLapTime)
+	assert.InDelta(t, 110+20-30, td.Cars[5].GapToClassLeader, 1e-3, "class estimate without a best lap")
+
+	t.Run("Next lap", func(t *testing.T) {
+		require.True(t, img.Next())
+
+		td := d.Telemetry()
+
+		assert.Equal(t, float32(98), td.Cars[1].LastLapTime)
+		assert.Equal(t, float32(98), td.Cars[1].BestLapTime)
+		assert.Equal(t, float32(99), td.Cars[1].AverageLapTime)
+		assert.Equal(t, float32(101), td.Cars[2].AverageLapTime, "same last lap is not a new lap")
+		assert.InDelta(t, 101+1-99, td.Cars[2].GapToClassLeader, 1e-3)
+	})
+
+	t.Run("History is cleared when the session changes", func(t *testing.T) {
+		session := memmap.RaceSession(285, 1001,
+			iryaml.Driver{CarIdx: 1, UserName: "Leader", UserID: 100, CarClassID: gtp, CarClassEstLapTime: 105})
+		require.NoError(t, img.SetSession(session))
+
+		td := d.Telemetry()
+
+		assert.Equal(t, 1001, td.SubsessionID)
+		assert.Equal(t, float32(98), td.Cars[1].AverageLapTime, "laps from the previous session are dropped")
+	})
+}
+
+func TestTrackersClearedOnDisconnect(t *testing.T) {
+	session := memmap.RaceSession(285, 1000, iryaml.Driver{CarIdx: 1, UserName: "Alice", UserID: 100, CarClassID: 84})
+
+	race := memmap.NewRace(session).
+		Tick(memmap.Values{
+			"SessionState":      irsdk.SessionStateRacing,
+			"CarIdxLap":         memmap.PerCar(map[int]int{1: 2}),
+			"CarIdxLastLapTime": memmap.PerCar(map[int]float32{1: 100}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap":         memmap.PerCar(map[int]int{1: 3}),
+			"CarIdxLastLapTime": memmap.PerCar(map[int]float32{1: 98}),
+		})
+
+	img, d := newTestData(t, race)
+
+	d.Telemetry()
+	require.True(t, img.Next())
+	assert.Equal(t, float32(99), d.Telemetry().Cars[1].AverageLapTime)
+
+	img.Disconnect()
+	assert.Equal(t, Waiting, d.Telemetry().Status)
+
+	// the same session again, as when iRacing restarts and rejoins it
+	require.NoError(t, img.Restart(session))
+	assert.Equal(t, float32(100), d.Telemetry().Cars[1].AverageLapTime, "laps before the disconnect are dropped")
+}
+
+func TestInterpolatedPositions(t *testing.T) {
+	type car struct {
+		class, position, laps int
+		pct                   float32
+		surface               irsdk.TrkLoc
+	}
+
+	tests := []struct {
+		name string
+		cars map[int]car
+		want map[int]int
+	}{
+		{
+			name: "Pass on track before the line",
+			cars: map[int]car{
+				1: {class: 84, position: 1, laps: 5, pct: 0.40, surface: irsdk.TrkLocOnTrack},
+				2: {class: 84, position: 2, laps: 5, pct: 0.41, surface: irsdk.TrkLocOnTrack},
+				3: {class: 84, position: 3, laps: 4, pct: 0.90, surface: irsdk.TrkLocOffTrack},
+			},
+			want: map[int]int{1: 2, 2: 1, 3: 3},
+		},
+		{
+			name: "Classes ranked separately",
+			cars: map[int]car{
+				1: {class: 84, position: 1, laps: 5, pct: 0.40, surface: irsdk.TrkLocOnTrack},
+				2: {class: 83, position: 1, laps: 4, pct: 0.20, surface: irsdk.TrkLocOnTrack},
+				3: {class: 83, position: 2, laps: 4, pct: 0.30, surface: irsdk.TrkLocOnTrack},
+			},
+			want: map[int]int{1: 1, 2: 2, 3: 1},
+		},
+		{
+			name: "Pit lane holds the official position",
+			cars: map[int]car{
+				1: {class: 84, position: 1, laps: 5, pct: 0.10, surface: irsdk.TrkLocInPitStall},
+				2: {class: 84, position: 2, laps: 5, pct: 0.50, surface: irsdk.TrkLocOnTrack},
+				3: {class: 84, position: 3, laps: 5, pct: 0.60, surface: irsdk.TrkLocOnTrack},
+				4: {class: 84, position: 4, laps: 5, pct: 0.99, surface: irsdk.TrkLocAproachingPits},
+			},
+			want: map[int]int{1: 1, 2: 3, 3: 2, 4: 4},
+		},
+		{
+			name: "Not in world holds the official position",
+			cars: map[int]car{
+				1: {class: 84, position: 1, laps: 5, pct: 0.50, surface: irsdk.TrkLocOnTrack},
+				2: {class: 84, position: 2, laps: 5, pct: -1, surface: irsdk.TrkLocNotInWorld},
+				3: {class: 84, position: 3, laps: 5, pct: 0.60, surface: irsdk.TrkLocOnTrack},
+			},
+			want: map[int]int{1: 3, 2: 2, 3: 1},
+		},
+		{
+			name: "Unclassified cars held last",
+			cars: map[int]car{
+				1: {class: 84, position: 0, laps: 0, pct: -1, surface: irsdk.TrkLocNotInWorld},
+				2: {class: 84, position: 0, laps: 1, pct: 0.20, surface: irsdk.TrkLocOnTrack},
+				3: {class: 84, position: 0, laps: 1, pct: 0.10, surface: irsdk.TrkLocOnTrack},
+			},
+			want: map[int]int{1: 3, 2: 1, 3: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var td TelemetryData
+
+			for carIdx, c := range tt.cars {
+				td.Cars[carIdx] = CarInfo{
+					DriverName: "Driver", CarClassID: c.class, RacePositionInClass: c.position,
+					LapsComplete: c.laps, LapDistPct: c.pct, TrackSurface: c.surface,
+				}
+			}
+
+			td.Cars[0] = CarInfo{DriverName: "Pace Car", IsPaceCar: true, CarClassID: 84, LapsComplete: 9}
+
+			td.updateInterpolatedPositions()
+
+			got := make(map[int]int)
+			for carIdx := range tt.cars {
+				got[carIdx] = td.Cars[carIdx].InterpolatedPositionInClass
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Zero(t, td.Cars[0].InterpolatedPositionInClass)
+		})
+	}
+}
+
+func TestRetirements(t *testing.T) {
+	drivers := []iryaml.Driver{
+		{CarIdx: 1, UserName: "Finisher", UserID: 100, CarClassID: 84},
+		{CarIdx: 2, UserName: "Towed", UserID: 200, CarClassID: 84},
+		{CarIdx: 3, UserName: "Vanished", UserID: 300, CarClassID: 84},
+		{CarIdx: 4, UserName: "Stopped", UserID: 400, CarClassID: 84},
+	}
+
+	onTrack := map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocOnTrack, 3: irsdk.TrkLocOnTrack, 4: irsdk.TrkLocOnTrack}
+
+	race := memmap.NewRace(memmap.RaceSession(285, 1000, drivers...)).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2, 3: 3, 4: 4}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{1: 3, 2: 3, 3: 3, 4: 3}),
+			"CarIdxTrackSurface":  memmap.PerCar(onTrack),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap":          memmap.PerCar(map[int]int{1: 4, 2: -1, 3: 4, 4: 3}),
+			"CarIdxTrackSurface": memmap.PerCar(map[int]irsdk.TrkLoc{1: 3, 2: irsdk.TrkLocNotInWorld, 3: 3, 4: 3}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap": memmap.PerCar(map[int]int{1: 5, 2: -1, 3: 5, 4: 3}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap": memmap.PerCar(map[int]int{1: 6, 2: -1, 3: -1, 4: 3}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap":          memmap.PerCar(map[int]int{1: 7, 2: 4, 3: -1, 4: 3}),
+			"CarIdxTrackSurface": memmap.PerCar(map[int]irsdk.TrkLoc{1: 3, 2: 3, 3: -1, 4: 3}),
+		}).
+		Tick(memmap.Values{
+			"SessionState":       irsdk.SessionStateCheckered,
+			"CarIdxLap":          memmap.PerCar(map[int]int{1: -1, 2: -1, 3: -1, 4: 3}),
+			"CarIdxTrackSurface": memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocNotInWorld, 2: -1, 3: -1, 4: 3}),
+		})
+
+	img, d := newTestData(t, race)
+
+	retired := func(td *TelemetryData) map[int]int {
+		laps := make(map[int]int)
+
+		for i := range td.Cars {
+			if td.Cars[i].Retired {
+				laps[i] = td.Cars[i].LapsComplete
+			}
+		}
+
+		return laps
+	}
+
+	assert.Empty(t, retired(d.Telemetry()), "all running")
+
+	require.True(t, img.Next())
+	assert.Equal(t, map[int]int{2: 3}, retired(d.Telemetry()), "not in world, laps frozen")
+
+	require.True(t, img.Next())
+	assert.Equal(t, map[int]int{2: 3, 4: 3}, retired(d.Telemetry()), "stopped while the leader completed two laps")
+
+	require.True(t, img.Next())
+	require.NoError(t, img.SetSession(memmap.RaceSession(285, 1000, drivers[0], drivers[1], drivers[3])))
+
+	td := d.Telemetry()
+	assert.Equal(t, map[int]int{2: 3, 3: 5, 4: 3}, retired(td), "vanished from the session")
+	assert.Equal(t, "Vanished", td.Cars[3].DriverName)
+	assert.Equal(t, 3, td.Cars[3].RacePositionInClass)
+	assert.True(t, td.Cars[3].Left)
+	assert.False(t, td.Cars[4].Left)
+
+	require.True(t, img.Next())
+	assert.Equal(t, map[int]int{2: 3, 3: 5, 4: 3}, retired(d.Telemetry()), "towed back and driving on, still retired")
+
+	require.True(t, img.Next())
+
+	td = d.Telemetry()
+	assert.Equal(t, map[int]int{2: 3, 3: 5, 4: 3}, retired(td), "leaving after the checkered flag is not a retirement")
+	assert.Equal(t, 7, td.Cars[1].LapsComplete)
+}
+
+func TestRetirementsMulticlass(t *testing.T) {
+	const (
+		gtp = 84
+		gto = 83
+	)
+
+	onTrack := map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocOnTrack, 3: irsdk.TrkLocOnTrack, 4: irsdk.TrkLocOnTrack}
+
+	// the GTP laps the GTOs while one GTO is repaired in the pits and another is parked on track
+	race := memmap.NewRace(memmap.RaceSession(285, 1000,
+		iryaml.Driver{CarIdx: 1, UserName: "Prototype", UserID: 100, CarClassID: gtp},
+		iryaml.Driver{CarIdx: 2, UserName: "Repaired", UserID: 200, CarClassID: gto},
+		iryaml.Driver{CarIdx: 3, UserName: "Parked", UserID: 300, CarClassID: gto},
+		iryaml.Driver{CarIdx: 4, UserName: "Leader", UserID: 400, CarClassID: gto},
+	)).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2, 3: 3, 4: 1}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{1: 5, 2: 3, 3: 3, 4: 3}),
+			"CarIdxTrackSurface":  memmap.PerCar(onTrack),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap":          memmap.PerCar(map[int]int{1: 7, 2: 3, 3: 3, 4: 4}),
+			"CarIdxOnPitRoad":    memmap.PerCar(map[int]bool{2: true}),
+			"CarIdxTrackSurface": memmap.PerCar(map[int]irsdk.TrkLoc{1: 3, 2: irsdk.TrkLocInPitStall, 3: 3, 4: 3}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap": memmap.PerCar(map[int]int{1: 8, 2: 3, 3: 3, 4: 5}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxOnPitRoad":    memmap.PerCar(map[int]bool{}),
+			"CarIdxTrackSurface": memmap.PerCar(onTrack),
+		})
+
+	img, d := newTestData(t, race)
+
+	retired := func(td *TelemetryData) []int {
+		var carIdxs []int
+
+		for i := range td.Cars {
+			if td.Cars[i].Retired {
+				carIdxs = append(carIdxs, i)
+			}
+		}
+
+		return carIdxs
+	}
+
+	assert.Empty(t, retired(d.Telemetry()))
+
+	require.True(t, img.Next())
+	assert.Empty(t, retired(d.Telemetry()), "two laps of the GTP are one of the GTO leader")
+
+	require.True(t, img.Next())
+	assert.Equal(t, []int{3}, retired(d.Telemetry()), "stalled on track for two laps of the class leader, not in the pits")
+
+	require.True(t, img.Next())
+	assert.Equal(t, []int{3}, retired(d.Telemetry()), "the repair does not count towards a stall")
+}
+
+func TestPitStops(t *testing.T) {
+	onTrack := map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocOnTrack}
+
+	// car 1 leads into the pits at the end of lap 5 and comes out behind car 2
+	race := memmap.NewRace(memmap.RaceSession(285, 1000,
+		iryaml.Driver{CarIdx: 1, UserName: "Pitter", UserID: 100, CarNumber: "1", CarClassID: 84},
+		iryaml.Driver{CarIdx: 2, UserName: "Stayer", UserID: 200, CarNumber: "2", CarClassID: 84},
+	)).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"SessionTime":         100.0,
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{1: 5, 2: 5}),
+			"CarIdxLapDistPct":    memmap.PerCar(map[int]float32{1: 0.9, 2: 0.8}),
+			"CarIdxTrackSurface":  memmap.PerCar(onTrack),
+		}).
+		Tick(memmap.Values{
+			"SessionTime":        105.0,
+			"CarIdxOnPitRoad":    memmap.PerCar(map[int]bool{1: true}),
+			"CarIdxTrackSurface": memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocAproachingPits, 2: irsdk.TrkLocOnTrack}),
+		}).
+		Tick(memmap.Values{
+			"SessionTime":        120.0,
+			"CarIdxTrackSurface": memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocInPitStall, 2: irsdk.TrkLocOnTrack}),
+		}).
+		Tick(memmap.Values{
+			"SessionTime":        135.0,
+			"CarIdxOnPitRoad":    memmap.PerCar(map[int]bool{}),
+			"CarIdxLap":          memmap.PerCar(map[int]int{1: 6, 2: 6}),
+			"CarIdxLapDistPct":   memmap.PerCar(map[int]float32{1: 0.05, 2: 0.10}),
+			"CarIdxTrackSurface": memmap.PerCar(onTrack),
+		}).
+		Tick(memmap.Values{
+			"SessionTime":      200.0,
+			"CarIdxLap":        memmap.PerCar(map[int]int{1: 7, 2: 7}),
+			"CarIdxLapDistPct": memmap.PerCar(map[int]float32{1: 0.01, 2: 0.02}),
+		})
+
+	img, d := newTestData(t, race)
+
+	td := d.Telemetry()
+	assert.Empty(t, td.PitStops)
+	assert.False(t, td.Cars[1].Pitting)
+
+	require.True(t, img.Next())
+
+	td = d.Telemetry()
+	assert.True(t, td.Cars[1].Pitting)
+	assert.False(t, td.Cars[2].Pitting)
+	assert.Equal(t, 1, td.Cars[1].PitStopCount)
+	assert.Equal(t, []PitStop{
+		{CarIdx: 1, CustID: 100, CarNumber: "1", DriverName: "Pitter", EntryLap: 5, EntryTime: 105, PositionAtEntry: 1},
+	}, td.PitStops, "open until the car exits")
+
+	require.True(t, img.Next())
+	assert.True(t, d.Telemetry().Cars[1].Pitting, "in the pit stall")
+
+	require.True(t, img.Next())
+
+	td = d.Telemetry()
+	assert.True(t, td.Cars[1].Pitting, "not back to the line since the stop")
+	assert.Equal(t, []PitStop{
+		{
+			CarIdx: 1, CustID: 100, CarNumber: "1", DriverName: "Pitter", EntryLap: 5, ExitLap: 6, Exited: true, EntryTime: 105,
+			TimeInPitLane: 30, PositionAtEntry: 1, PositionAtExit: 2, PositionsLost: 1,
+		},
+	}, td.PitStops)
+
+	require.True(t, img.Next())
+
+	td = d.Telemetry()
+	assert.False(t, td.Cars[1].Pitting, "crossed the line")
+	assert.Equal(t, 1, td.Cars[1].PitStopCount)
+	assert.Zero(t, td.Cars[2].PitStopCount)
+}
+
+func TestPitLaneStart(t *testing.T) {
+	race := memmap.NewRace(memmap.RaceSession(285, 1000,
+		iryaml.Driver{CarIdx: 1, UserName: "Pitter", UserID: 100, CarNumber: "1", CarClassID: 84},
+	)).
+		Tick(memmap.Values{
+			"SessionState":       irsdk.SessionStateRacing,
+			"SessionTime":        10.0,
+			"CarIdxOnPitRoad":    memmap.PerCar(map[int]bool{1: true}),
+			"CarIdxTrackSurface": memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocInPitStall}),
+		}).
+		Tick(memmap.Values{
+			"SessionTime":        30.0,
+			"CarIdxOnPitRoad":    memmap.PerCar(map[int]bool{}),
+			"CarIdxTrackSurface": memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap": memmap.PerCar(map[int]int{1: 1}),
+		})
+
+	img, d := newTestData(t, race)
+
+	td := d.Telemetry()
+	require.Len(t, td.PitStops, 1)
+	assert.False(t, td.PitStops[0].Exited)
+
+	require.True(t, img.Next())
+
+	td = d.Telemetry()
+	assert.True(t, td.PitStops[0].Exited, "left on lap 0")
+	assert.Zero(t, td.PitStops[0].ExitLap)
+	assert.InDelta(t, 20, td.PitStops[0].TimeInPitLane, 1e-9)
+	assert.True(t, td.Cars[1].Pitting, "not back to the line")
+
+	require.True(t, img.Next())
+	assert.False(t, d.Telemetry().Cars[1].Pitting, "crossed the line")
+}
+
+func TestIncidents(t *testing.T) {
+	sessionOf := func(alice, bob int) iryaml.IRSession {
+		session := memmap.RaceSession(285, 1000,
+			iryaml.Driver{CarIdx: 1, UserName: "Alice", UserID: 100, CarClassID: 84, CurDriverIncidentCount: alice, TeamIncidentCount: alice},
+			iryaml.Driver{CarIdx: 2, UserName: "Bob", UserID: 200, CarClassID: 84, CurDriverIncidentCount: bob, TeamIncidentCount: bob + 3},
+		)
+		session.WeekendInfo.WeekendOptions.IncidentLimit = "17"
+
+		return session
+	}
+
+	race := memmap.NewRace(sessionOf(2, 0)).
+		Tick(memmap.Values{
+			"SessionState": irsdk.SessionStateRacing,
+			"CarIdxLap":    memmap.PerCar(map[int]int{1: 3, 2: 3}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap": memmap.PerCar(map[int]int{1: 12, 2: 11}),
+		})
+
+	img, d := newTestData(t, race)
+
+	td := d.Telemetry()
+	assert.Equal(t, 17, td.IncidentLimit)
+	assert.Equal(t, 2, td.Cars[1].Incidents)
+	assert.Equal(t, 3, td.Cars[2].TeamIncidents)
+	assert.Empty(t, td.Cars[1].IncidentDeltas, "counts when first seen are the baseline")
+
+	require.NoError(t, img.SetSession(sessionOf(2, 4)))
+
+	td = d.Telemetry()
+	assert.Equal(t, []IncidentDelta{{Lap: 3, Count: 4, Total: 7}}, td.Cars[2].IncidentDeltas)
+
+	require.True(t, img.Next())
+	require.NoError(t, img.SetSession(sessionOf(6, 5)))
+
+	td = d.Telemetry()
+	assert.Equal(t, []IncidentDelta{{Lap: 12, Count: 4, Total: 6}}, td.Cars[1].IncidentDeltas)
+	assert.Equal(t, []IncidentDelta{{Lap: 3, Count: 4, Total: 7}, {Lap: 11, Count: 1, Total: 8}}, td.Cars[2].IncidentDeltas)
+	assert.Equal(t, "+1x on lap 11", td.Cars[2].IncidentDeltas[1].String())
+
+	t.Run("Unlimited", func(t *testing.T) {
+		session := sessionOf(6, 5)
+		session.WeekendInfo.WeekendOptions.IncidentLimit = "unlimited"
+		require.NoError(t, img.SetSession(session))
+
+		assert.Zero(t, d.Telemetry().IncidentLimit)
+	})
+}
+
+func TestSessionResults(t *testing.T) {
+	session := memmap.RaceSession(285, 1000,
+		iryaml.Driver{CarIdx: 1, UserName: "Alice", UserID: 100, CarClassID: 84},
+		iryaml.Driver{CarIdx: 2, UserName: "Bob", UserID: 200, CarClassID: 84},
+	)
+	session.SessionInfo.Sessions[0].ResultsPositions = []iryaml.ResultsPosition{
+		{Position: 1, ClassPosition: 0, CarIdx: 2, LapsComplete: 12, ReasonOutStr: "Running"},
+		{Position: 2, ClassPosition: 1, CarIdx: 1, LapsComplete: 6, ReasonOutStr: "Disconnected"},
+	}
+
+	race := memmap.NewRace(session).
+		Tick(memmap.Values{"SessionState": irsdk.SessionStateRacing}).
+		Tick(memmap.Values{"SessionState": irsdk.SessionStateCheckered})
+
+	img, d := newTestData(t, race)
+
+	td := d.Telemetry()
+	assert.False(t, td.Checkered())
+	assert.False(t, td.ResultsOfficial)
+	assert.Equal(t, []SessionResult{
+		{CarIdx: 2, Position: 1, ClassPosition: 0, LapsComplete: 12, ReasonOut: ReasonOutRunning},
+		{CarIdx: 1, Position: 2, ClassPosition: 1, LapsComplete: 6, ReasonOut: "Disconnected"},
+	}, td.Results)
+
+	require.True(t, img.Next())
+	assert.True(t, d.Telemetry().Checkered())
+
+	session.SessionInfo.Sessions[0].ResultsOfficial = 1
+	require.NoError(t, img.SetSession(session))
+	assert.True(t, d.Telemetry().ResultsOfficial)
+}
+
+func TestTeams(t *testing.T) {
+	sessionOf := func(driver iryaml.Driver) iryaml.IRSession {
+		session := memmap.RaceSession(285, 1000,
+			driver,
+			iryaml.Driver{CarIdx: 2, UserName: "Solo", UserID: 300, CarClassID: 84},
+		)
+		session.WeekendInfo.TeamRacing = 1
+
+		return session
+	}
+
+	alice := iryaml.Driver{CarIdx: 1, UserName: "Alice", UserID: 100, IRating: 3000, TeamID: 10, TeamName: "Team\r\nA", CarClassID: 84}
+	bob := alice
+	bob.UserName, bob.UserID, bob.IRating = "Bob", 200, 2000
+
+	race := memmap.NewRace(sessionOf(alice)).
+		Tick(memmap.Values{"SessionState": irsdk.SessionStateRacing})
+
+	img, d := newTestData(t, race)
+
+	td := d.Telemetry()
+	assert.True(t, td.TeamRacing)
+	assert.Equal(t, 10, td.Cars[1].TeamID)
+	assert.Equal(t, "TeamA", td.Cars[1].TeamName)
+	assert.Equal(t, []TeamDriver{{CustID: 100, DriverName: "Alice", IRating: 3000}}, td.Cars[1].Drivers)
+	assert.Equal(t, []TeamDriver{{CustID: 300, DriverName: "Solo"}}, td.Cars[2].Drivers)
+
+	require.NoError(t, img.SetSession(sessionOf(bob)))
+
+	td = d.Telemetry()
+	assert.Equal(t, 200, td.Cars[1].CustID, "driving now")
+	assert.Equal(t, []TeamDriver{
+		{CustID: 100, DriverName: "Alice", IRating: 3000},
+		{CustID: 200, DriverName: "Bob", IRating: 2000},
+	}, td.Cars[1].Drivers)
+
+	require.NoError(t, img.SetSession(sessionOf(alice)))
+	assert.Len(t, d.Telemetry().Cars[1].Drivers, 2, "a driver's second stint")
+
+	t.Run("A different entry in the car starts again", func(t *testing.T) {
+		other := alice
+		other.TeamID, other.TeamName = 11, "Team B"
+		require.NoError(t, img.SetSession(sessionOf(other)))
+
+		assert.Equal(t, []TeamDriver{{CustID: 100, DriverName: "Alice", IRating: 3000}}, d.Telemetry().Cars[1].Drivers)
+	})
+}
+
+func TestFlagOf(t *testing.T) {
+	tests := []struct {
+		flags irsdk.SessionFlags
+		want  Flag
+	}{
+		{flags: 0, want: NoFlag},
+		{flags: irsdk.FlagGreen | irsdk.FlagStartGo, want: GreenFlag},
+		{flags: irsdk.FlagGreen | irsdk.FlagYellowWaving, want: YellowFlag},
+		{flags: irsdk.FlagYellow | irsdk.FlagCaution, want: CautionFlag},
+		{flags: irsdk.FlagCautionWaving, want: CautionFlag},
+		{flags: irsdk.FlagGreen | irsdk.FlagWhite | irsdk.FlagYellow, want: WhiteFlag},
+		{flags: irsdk.FlagWhite | irsdk.FlagCheckered, want: CheckeredFlag},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, FlagOf(tt.flags), tt.flags.String())
+	}
+}
+
+func TestSessionClock(t *testing.T) {
+	race := memmap.NewRace(memmap.RaceSession(285, 1000)).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"SessionFlags":        irsdk.FlagGreen,
+			"SessionTimeRemain":   1234.5,
+			"SessionLapsRemainEx": 32767,
+		}).
+		Tick(memmap.Values{
+			"SessionFlags":        irsdk.FlagWhite,
+			"SessionTimeRemain":   604800.0,
+			"SessionLapsRemainEx": 1,
+		})
+
+	img, d := newTestData(t, race)
+
+	td := d.Telemetry()
+	assert.Equal(t, GreenFlag, td.Flag)
+	assert.InDelta(t, 1234.5, td.TimeRemaining, 1e-9)
+	assert.Equal(t, Unlimited, td.LapsRemaining, "timed race")
+	assert.False(t, td.FinalLap())
+
+	require.True(t, img.Next())
+
+	td = d.Telemetry()
+	assert.Equal(t, WhiteFlag, td.Flag)
+	assert.InDelta(t, Unlimited, td.TimeRemaining, 1e-9, "lap race")
+	assert.Equal(t, 1, td.LapsRemaining)
+	assert.True(t, td.FinalLap())
+}
@@ -10,6 +10,10 @@ func byte4ToInt(in []byte) int {
 	return int(binary.LittleEndian.Uint32(in))
 }
 
+func byte4ToSignedInt(in []byte) int {
+	return int(int32(binary.LittleEndian.Uint32(in)))
+}
+
 func byte4ToFloat(in []byte) float32 {
 	bits := binary.LittleEndian.Uint32(in)
 	return math.Float32frombits(bits)
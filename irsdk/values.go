@@ -0,0 +1,100 @@
+package irsdk
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// VarGetter is satisfied by the SDK
+type VarGetter interface {
+	GetVar(name string) (Variable, error)
+}
+
+// VarValue are the Go types telemetry variables decode to, including named types like SessionFlags
+type VarValue interface {
+	~string | ~bool | ~int | ~int32 | ~uint32 | ~float32 | ~float64
+}
+
+// Value of the first element of a telemetry variable, or ErrTypeMismatch if it does not decode to T
+func Value[T VarValue](vars VarGetter, name string) (T, error) {
+	var value T
+
+	values, err := Values[T](vars, name)
+	if err != nil {
+		return value, err
+	}
+
+	if len(values) == 0 {
+		return value, fmt.Errorf("%w: %q has no value yet", ErrUnknownVariable, name)
+	}
+
+	return values[0], nil
+}
+
+// Values of all elements of a telemetry variable, e.g. per CarIdx, or ErrTypeMismatch if they do not decode to T
+func Values[T VarValue](vars VarGetter, name string) ([]T, error) {
+	v, err := vars.GetVar(name)
+	if err != nil {
+		return nil, err
+	}
+
+	target := reflect.TypeOf((*T)(nil)).Elem()
+
+	if !v.VarType.decodesTo(target.Kind()) {
+		return nil, fmt.Errorf("%w: %q is %s not %s", ErrTypeMismatch, name, v.VarType, target)
+	}
+
+	src := reflect.ValueOf(v.Values)
+	if !src.IsValid() {
+		return []T{}, nil
+	}
+
+	values := make([]T, src.Len())
+	dst := reflect.ValueOf(values)
+
+	for i := range values {
+		dst.Index(i).Set(src.Index(i).Convert(target))
+	}
+
+	return values, nil
+}
+
+func (vt VarType) decodesTo(kind reflect.Kind) bool {
+	switch vt {
+	case VarTypeChar:
+		return kind == reflect.String
+	case VarTypeBool:
+		return kind == reflect.Bool
+	case VarTypeInt, VarTypeBitField:
+		return kind == reflect.Int || kind == reflect.Int32 || kind == reflect.Uint32
+	case VarTypeFloat:
+		return kind == reflect.Float32
+	case VarTypeDouble:
+		return kind == reflect.Float64
+	case VarTypeETCount:
+		return false
+	default:
+		return false
+	}
+}
+
+func (vt VarType) String() string {
+	switch vt {
+	case VarTypeChar:
+		return "char"
+	case VarTypeBool:
+		return "bool"
+	case VarTypeInt:
+		return "int"
+	case VarTypeBitField:
+		return "bitfield"
+	case VarTypeFloat:
+		return "float"
+	case VarTypeDouble:
+		return "double"
+	case VarTypeETCount:
+		return fmt.Sprintf("Unknown (%d)", int(vt))
+	default:
+		return fmt.Sprintf("Unknown (%d)", int(vt))
+	}
+}
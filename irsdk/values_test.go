@@ -0,0 +1,100 @@
+package irsdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubVars map[string]Variable
+
+func (s stubVars) GetVar(name string) (Variable, error) {
+	v, ok := s[name]
+	if !ok {
+		return Variable{}, ErrUnknownVariable
+	}
+
+	return v, nil
+}
+
+// decoded as readVariableValues would from little endian raw bytes
+func stubVar(varType VarType, raw ...byte) Variable {
+	count := len(raw) / varTypeBytes[varType]
+	values := makeValues(varType, count)
+	decodeValues(varType, raw, values)
+
+	return Variable{VarType: varType, Count: count, Values: values, RawBytes: raw}.snapshot()
+}
+
+func TestValues(t *testing.T) {
+	vars := stubVars{
+		"CarIdxLap":          stubVar(VarTypeInt, 0xff, 0xff, 0xff, 0xff, 12, 0, 0, 0),
+		"SessionState":       stubVar(VarTypeInt, 4, 0, 0, 0),
+		"CarIdxTrackSurface": stubVar(VarTypeInt, 0xff, 0xff, 0xff, 0xff, 3, 0, 0, 0),
+		"SessionFlags":       stubVar(VarTypeBitField, 0x04, 0x00, 0x00, 0x90),
+		"Speed":              stubVar(VarTypeFloat, 0, 0, 0x80, 0x3f),
+		"OnPitRoad":          stubVar(VarTypeBool, 1),
+	}
+
+	t.Run("Signed ints", func(t *testing.T) {
+		laps, err := Values[int](vars, "CarIdxLap")
+		require.NoError(t, err)
+		assert.Equal(t, []int{-1, 12}, laps)
+	})
+
+	t.Run("Enums", func(t *testing.T) {
+		state, err := Value[SessionState](vars, "SessionState")
+		require.NoError(t, err)
+		assert.Equal(t, SessionStateRacing, state)
+		assert.Equal(t, "Racing", state.String())
+
+		surfaces, err := Values[TrkLoc](vars, "CarIdxTrackSurface")
+		require.NoError(t, err)
+		assert.Equal(t, []TrkLoc{TrkLocNotInWorld, TrkLocOnTrack}, surfaces)
+	})
+
+	t.Run("Bitfields are unsigned", func(t *testing.T) {
+		flags, err := Value[SessionFlags](vars, "SessionFlags")
+		require.NoError(t, err)
+		assert.True(t, flags.Has(FlagGreen))
+		assert.True(t, flags.Has(FlagStartGo))
+		assert.False(t, flags.Has(FlagYellow))
+		assert.Equal(t, "green|startHidden|startGo", flags.String())
+	})
+
+	t.Run("Floats and bools", func(t *testing.T) {
+		speed, err := Value[float32](vars, "Speed")
+		require.NoError(t, err)
+		assert.Equal(t, float32(1), speed)
+
+		onPitRoad, err := Value[bool](vars, "OnPitRoad")
+		require.NoError(t, err)
+		assert.True(t, onPitRoad)
+	})
+
+	t.Run("Type mismatch", func(t *testing.T) {
+		_, err := Value[int](vars, "Speed")
+		assert.ErrorIs(t, err, ErrTypeMismatch)
+
+		_, err = Values[float64](vars, "Speed")
+		assert.ErrorIs(t, err, ErrTypeMismatch)
+
+		_, err = Value[SessionFlags](vars, "OnPitRoad")
+		assert.ErrorIs(t, err, ErrTypeMismatch)
+	})
+
+	t.Run("Unknown variable", func(t *testing.T) {
+		_, err := Value[int](vars, "Unknown")
+		assert.ErrorIs(t, err, ErrUnknownVariable)
+	})
+}
+
+func TestFlagNames(t *testing.T) {
+	assert.Equal(t, "", SessionFlags(0).String())
+	assert.Equal(t, "checkered|0x200000", (FlagCheckered | 0x200000).String())
+	assert.Equal(t, []string{"engineStalled", "pitSpeedLimiter"}, (EngineWarningStalled | EngineWarningPitSpeedLimiter).Names())
+	assert.Equal(t, "IsSessionScreen|UIHidden", (CameraStateIsSessionScreen | CameraStateUIHidden).String())
+	assert.Equal(t, "TrkLoc(7)", TrkLoc(7).String())
+	assert.Equal(t, "SessionState(9)", SessionState(9).String())
+}
@@ -8,9 +8,10 @@ import (
 	"time"
 )
 
+// VarBuffer of the var buffer array in the header, the row for a tick
 type VarBuffer struct {
 	TickCount int // used to detect changes in data
-	bufOffset int // offset from header
+	BufOffset int // offset from header
 }
 
 type VarType int
@@ -67,196 +68,225 @@ func (v Variable) String() string {
 type TelemetryVars struct {
 	lastVersion int
 	vars        map[string]Variable
+	row         []byte // latest var buffer row, reused every tick
 	mux         sync.Mutex
 }
 
-func findLatestBuffer(r reader, h *header) VarBuffer {
+// Size of one element of the type in bytes, 0 if unknown
+func (vt VarType) Size() int {
+	return varTypeBytes[vt]
+}
+
+// varTypeBytes is the size of one element of each VarType
+var varTypeBytes = map[VarType]int{
+	VarTypeChar:     1,
+	VarTypeBool:     1,
+	VarTypeInt:      4,
+	VarTypeBitField: 4,
+	VarTypeFloat:    4,
+	VarTypeDouble:   8,
+}
+
+func findLatestBuffer(r reader, h *header) (VarBuffer, error) {
 	var vb VarBuffer
 
-	foundTickCount := 0
+	rbuf := make([]byte, h.numBuf*varBufSize)
 
-	for i := 0; i < h.numBuf; i++ {
-		rbuf := make([]byte, 16)
+	err := readAt(r, rbuf, headerSize, "var buffers")
+	if err != nil {
+		return vb, err
+	}
 
-		_, err := r.ReadAt(rbuf, int64(48+i*16))
-		if err != nil {
-			log.Fatal(err)
-		}
+	foundTickCount := 0
 
+	for i := 0; i < h.numBuf; i++ {
 		currentVb := VarBuffer{
-			byte4ToInt(rbuf[0:4]),
-			byte4ToInt(rbuf[4:8]),
+			byte4ToInt(rbuf[i*varBufSize : i*varBufSize+4]),
+			byte4ToInt(rbuf[i*varBufSize+4 : i*varBufSize+8]),
 		}
 
-		// fmt.Printf("BUFF?: %+v\n", currentVb)
-
 		if foundTickCount < currentVb.TickCount {
 			foundTickCount = currentVb.TickCount
 			vb = currentVb
 		}
 	}
 
-	// fmt.Printf("BUFF: %+v\n", vb)
-
-	return vb
+	return vb, nil
 }
 
-func readVariableHeaders(r reader, h *header) *TelemetryVars {
+func readVariableHeaders(r reader, h *header) (*TelemetryVars, error) {
 	vars := TelemetryVars{vars: make(map[string]Variable, h.numVars)}
 
-	for i := 0; i < h.numVars; i++ {
-		rbuf := make([]byte, 144)
+	rbuf := make([]byte, h.numVars*varHeaderSize)
 
-		_, err := r.ReadAt(rbuf, int64(h.headerOffset+i*144))
-		if err != nil {
-			log.Fatal(err)
-		}
+	err := readAt(r, rbuf, int64(h.headerOffset), "variable headers")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < h.numVars; i++ {
+		vh := rbuf[i*varHeaderSize : (i+1)*varHeaderSize]
 
 		v := Variable{
-			VarType(byte4ToInt(rbuf[0:4])),
-			byte4ToInt(rbuf[4:8]),
-			byte4ToInt(rbuf[8:12]),
-			int(rbuf[12]) > 0,
-			bytesToString(rbuf[16:48]),
-			bytesToString(rbuf[48:112]),
-			bytesToString(rbuf[112:144]),
+			VarType(byte4ToInt(vh[0:4])),
+			byte4ToInt(vh[4:8]),
+			byte4ToInt(vh[8:12]),
+			int(vh[12]) > 0,
+			bytesToString(vh[16:48]),
+			bytesToString(vh[48:112]),
+			bytesToString(vh[112:144]),
 			nil,
 			nil,
 			nil,
 		}
 
-		vars.vars[v.Name] = v
-	}
-
-	return &vars
-}
-
-//nolint:funlen,gocognit,gocyclo // Engage brain
-func readVariableValues(sdk *IRSDK) bool {
-	newData := false
-
-	if sessionStatusOK(sdk.h.status) {
-		// find latest buffer for variables
-		vb := findLatestBuffer(sdk.r, sdk.h)
-		sdk.tVars.mux.Lock()
-
-		if sdk.tVars.lastVersion < vb.TickCount {
-			newData = true
-			sdk.tVars.lastVersion = vb.TickCount
-			sdk.lastValidData = time.Now().Unix()
-
-			for varName, v := range sdk.tVars.vars {
-				var rbuf []byte
-
-				switch v.VarType {
-				case VarTypeChar:
-					values := make([]string, v.Count)
-
-					for i := 0; i < v.Count; i++ {
-						rbuf = make([]byte, 1)
-
-						_, err := sdk.r.ReadAt(rbuf, int64(vb.bufOffset+v.offset+(1*i)))
-						if err != nil {
-							log.Fatal(err)
-						}
-
-						values[i] = string(rbuf[0])
-					}
-
-					v.Value = values[0]
-					v.Values = values
-				case VarTypeBool:
-					values := make([]bool, v.Count)
-
-					for i := 0; i < v.Count; i++ {
-						rbuf = make([]byte, 1)
+		size, ok := varTypeBytes[v.VarType]
+		if !ok {
+			log.Printf("unknown var type: %d for %s", v.VarType, v.Name)
+			continue
+		}
 
-						_, err := sdk.r.ReadAt(rbuf, int64(vb.bufOffset+v.offset+(1*i)))
-						if err != nil {
-							log.Fatal(err)
-						}
+		if v.Count <= 0 || v.offset < 0 || v.offset+size*v.Count > h.bufLen {
+			return nil, fmt.Errorf("%w: variable %s outside the var buffer", ErrCorruptHeader, v.Name)
+		}
 
-						values[i] = int(rbuf[0]) > 0
-					}
+		vars.vars[v.Name] = v
+	}
 
-					v.Value = values[0]
-					v.Values = values
-				case VarTypeInt:
-					values := make([]int, v.Count)
+	vars.row = make([]byte, h.bufLen)
 
-					for i := 0; i < v.Count; i++ {
-						rbuf = make([]byte, 4)
+	return &vars, nil
+}
 
-						_, err := sdk.r.ReadAt(rbuf, int64(vb.bufOffset+v.offset+(4*i)))
-						if err != nil {
-							log.Fatal(err)
-						}
+// readVariableValues reads the latest var buffer row in one go and decodes the subscribed variables from it.
+// The decoded values are kept in slices reused every tick, so GetVar hands out copies.
+func readVariableValues(sdk *IRSDK) (bool, error) {
+	if !sessionStatusOK(sdk.h.status) {
+		return false, nil
+	}
 
-						values[i] = byte4ToInt(rbuf)
-					}
+	// find latest buffer for variables
+	vb, err := findLatestBuffer(sdk.r, sdk.h)
+	if err != nil {
+		return false, err
+	}
 
-					v.Value = values[0]
-					v.Values = values
-				case VarTypeBitField:
-					values := make([]int, v.Count)
+	sdk.tVars.mux.Lock()
+	defer sdk.tVars.mux.Unlock()
 
-					for i := 0; i < v.Count; i++ {
-						rbuf = make([]byte, 4)
+	if sdk.tVars.lastVersion >= vb.TickCount {
+		return false, nil
+	}
 
-						_, err := sdk.r.ReadAt(rbuf, int64(vb.bufOffset+v.offset+(4*i)))
-						if err != nil {
-							log.Fatal(err)
-						}
+	err = readAt(sdk.r, sdk.tVars.row, int64(vb.BufOffset), "var buffer row")
+	if err != nil {
+		return false, err
+	}
 
-						values[i] = byte4ToInt(rbuf)
-					}
+	if sdk.subscribed == nil {
+		for varName := range sdk.tVars.vars {
+			sdk.tVars.decode(varName)
+		}
+	} else {
+		for varName := range sdk.subscribed {
+			sdk.tVars.decode(varName)
+		}
+	}
 
-					v.Value = values[0]
-					v.Values = values
-				case VarTypeFloat:
-					values := make([]float32, v.Count)
+	sdk.tVars.lastVersion = vb.TickCount
+	sdk.lastValidData = time.Now().Unix()
 
-					for i := 0; i < v.Count; i++ {
-						rbuf = make([]byte, 4)
+	return true, nil
+}
 
-						_, err := sdk.r.ReadAt(rbuf, int64(vb.bufOffset+v.offset+(4*i)))
-						if err != nil {
-							log.Fatal(err)
-						}
+// decode the variable from the current row, must be called with the lock held
+func (tv *TelemetryVars) decode(varName string) {
+	v, ok := tv.vars[varName]
+	if !ok {
+		return
+	}
 
-						values[i] = byte4ToFloat(rbuf)
-					}
+	v.RawBytes = tv.row[v.offset : v.offset+varTypeBytes[v.VarType]*v.Count]
 
-					v.Value = values[0]
-					v.Values = values
-				case VarTypeDouble:
-					values := make([]float64, v.Count)
-
-					for i := 0; i < v.Count; i++ {
-						rbuf = make([]byte, 8)
+	if v.Values == nil {
+		v.Values = makeValues(v.VarType, v.Count)
+	}
 
-						_, err := sdk.r.ReadAt(rbuf, int64(vb.bufOffset+v.offset+(8*i)))
-						if err != nil {
-							log.Fatal(err)
-						}
+	decodeValues(v.VarType, v.RawBytes, v.Values)
 
-						values[i] = byte8ToFloat(rbuf)
-					}
+	tv.vars[varName] = v
+}
 
-					v.Value = values[0]
-					v.Values = values
-				case VarTypeETCount:
-					log.Printf("unknown var type: %d", v.VarType)
-				default:
-					log.Printf("unknown var type: %d", v.VarType)
-				}
+func makeValues(varType VarType, count int) interface{} {
+	switch varType {
+	case VarTypeChar:
+		return make([]string, count)
+	case VarTypeBool:
+		return make([]bool, count)
+	case VarTypeInt, VarTypeBitField:
+		return make([]int, count)
+	case VarTypeFloat:
+		return make([]float32, count)
+	case VarTypeDouble:
+		return make([]float64, count)
+	case VarTypeETCount:
+		return nil
+	default:
+		return nil
+	}
+}
 
-				v.RawBytes = rbuf
-				sdk.tVars.vars[varName] = v
+// decodeValues from the raw bytes into the slice made by makeValues, ints are signed, bitfields are not
+func decodeValues(varType VarType, raw []byte, values interface{}) {
+	switch vals := values.(type) {
+	case []string:
+		for i := range vals {
+			vals[i] = string(raw[i])
+		}
+	case []bool:
+		for i := range vals {
+			vals[i] = raw[i] > 0
+		}
+	case []int:
+		for i := range vals {
+			if varType == VarTypeInt {
+				vals[i] = byte4ToSignedInt(raw[i*4 : i*4+4])
+			} else {
+				vals[i] = byte4ToInt(raw[i*4 : i*4+4])
 			}
 		}
-		sdk.tVars.mux.Unlock()
+	case []float32:
+		for i := range vals {
+			vals[i] = byte4ToFloat(raw[i*4 : i*4+4])
+		}
+	case []float64:
+		for i := range vals {
+			vals[i] = byte8ToFloat(raw[i*8 : i*8+8])
+		}
+	}
+}
+
+// snapshot of the variable safe to hand out after the next tick overwrites the shared buffers
+func (v Variable) snapshot() Variable {
+	v.RawBytes = append([]byte(nil), v.RawBytes...)
+
+	switch vals := v.Values.(type) {
+	case []string:
+		v.Values = append([]string(nil), vals...)
+		v.Value = vals[0]
+	case []bool:
+		v.Values = append([]bool(nil), vals...)
+		v.Value = vals[0]
+	case []int:
+		v.Values = append([]int(nil), vals...)
+		v.Value = vals[0]
+	case []float32:
+		v.Values = append([]float32(nil), vals...)
+		v.Value = vals[0]
+	case []float64:
+		v.Values = append([]float64(nil), vals...)
+		v.Value = vals[0]
 	}
 
-	return newData
+	return v
 }
@@ -0,0 +1,187 @@
+package irsdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryMap struct {
+	*bytes.Reader
+}
+
+func (memoryMap) Close() error { return nil }
+
+// buildTestMemoryMap of numVars float arrays of count cars, element i of variable n holds n*1000+i
+func buildTestMemoryMap(numVars, count int) []byte {
+	bufLen := numVars * count * 4
+	varHeaderOffset := varBufsEnd
+	sessionOffset := varHeaderOffset + numVars*varHeaderSize
+	rowStart := sessionOffset + len(testSessionYaml)
+
+	img := make([]byte, rowStart+bufLen)
+
+	mh := MemHeader{
+		Version:           2,
+		Connected:         true,
+		TickRate:          60,
+		SessionInfoUpdate: 1,
+		SessionInfoLen:    len(testSessionYaml),
+		SessionInfoOffset: sessionOffset,
+		NumVars:           numVars,
+		VarHeaderOffset:   varHeaderOffset,
+		BufLen:            bufLen,
+		VarBufs:           []VarBuffer{{TickCount: 1, BufOffset: rowStart}},
+	}
+	mh.Put(img)
+
+	copy(img[sessionOffset:], testSessionYaml)
+
+	for n := 0; n < numVars; n++ {
+		vh := VarHeader{Type: VarTypeFloat, Offset: n * count * 4, Count: count, Name: fmt.Sprintf("CarIdxVar%d", n)}
+		vh.Put(img[varHeaderOffset+n*VarHeaderSize:])
+
+		for i := 0; i < count; i++ {
+			binary.LittleEndian.PutUint32(img[rowStart+(n*count+i)*4:], math.Float32bits(float32(n*1000+i)))
+		}
+	}
+
+	return img
+}
+
+func TestReadVariableValues(t *testing.T) {
+	img := buildTestMemoryMap(3, 64)
+
+	sdk := initTestSDK(t, memoryMap{bytes.NewReader(img)})
+
+	v, err := sdk.GetVar("CarIdxVar2")
+	require.NoError(t, err)
+
+	assert.Equal(t, 64, v.Count)
+	assert.Equal(t, float32(2000), v.Value)
+	assert.Equal(t, float32(2063), v.Values.([]float32)[63])
+	assert.Len(t, v.RawBytes, 64*4)
+
+	t.Run("Values handed out are not overwritten by the next tick", func(t *testing.T) {
+		binary.LittleEndian.PutUint32(img[headerSize:], 2)
+
+		rowStart := len(img) - 3*64*4
+		binary.LittleEndian.PutUint32(img[rowStart+2*64*4:], math.Float32bits(-1))
+
+		assert.True(t, waitForTestData(t, sdk))
+
+		next, err := sdk.GetVar("CarIdxVar2")
+		require.NoError(t, err)
+
+		assert.Equal(t, float32(-1), next.Value)
+		assert.Equal(t, float32(2000), v.Values.([]float32)[0])
+	})
+
+	t.Run("Variable outside the row", func(t *testing.T) {
+		binary.LittleEndian.PutUint32(img[36:], 4)
+
+		_, err := Init(memoryMap{bytes.NewReader(img)})
+		assert.ErrorIs(t, err, ErrCorruptHeader)
+	})
+}
+
+// BenchmarkReadVariableValues decodes a tick of 300 64 car arrays, report allocations with -benchmem
+func BenchmarkReadVariableValues(b *testing.B) {
+	img := buildTestMemoryMap(300, 64)
+
+	sdk, err := Init(memoryMap{bytes.NewReader(img)})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		sdk.tVars.lastVersion = 0
+
+		ok, err := readVariableValues(sdk)
+		if err != nil || !ok {
+			b.Fatal(ok, err)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	img := buildTestMemoryMap(3, 64)
+
+	sdk := initTestSDK(t, memoryMap{bytes.NewReader(img)})
+
+	sdk.Subscribe("CarIdxVar0", "CarIdxVar2")
+
+	_, err := sdk.GetVar("CarIdxVar1")
+	assert.ErrorIs(t, err, ErrNotSubscribed)
+
+	_, err = sdk.GetVar("Unknown")
+	assert.ErrorIs(t, err, ErrUnknownVariable)
+
+	vars, err := sdk.GetVars()
+	assert.NoError(t, err)
+	assert.Len(t, vars, 2)
+
+	t.Run("Only subscribed variables are decoded", func(t *testing.T) {
+		binary.LittleEndian.PutUint32(img[headerSize:], 2)
+
+		rowStart := len(img) - 3*64*4
+		binary.LittleEndian.PutUint32(img[rowStart:], math.Float32bits(-1))
+		binary.LittleEndian.PutUint32(img[rowStart+64*4:], math.Float32bits(-1))
+
+		assert.True(t, waitForTestData(t, sdk))
+
+		v0, err := sdk.GetVarValue("CarIdxVar0")
+		assert.NoError(t, err)
+		assert.Equal(t, float32(-1), v0)
+
+		assert.Equal(t, float32(1000), sdk.tVars.vars["CarIdxVar1"].Values.([]float32)[0])
+	})
+
+	t.Run("Newly subscribed variables are decoded from the latest row", func(t *testing.T) {
+		sdk.Subscribe("CarIdxVar1")
+
+		v1, err := sdk.GetVarValue("CarIdxVar1")
+		assert.NoError(t, err)
+		assert.Equal(t, float32(-1), v1)
+	})
+
+	t.Run("Subscribe to all", func(t *testing.T) {
+		sdk.Subscribe()
+
+		vars, err := sdk.GetVars()
+		assert.NoError(t, err)
+		assert.Len(t, vars, 3)
+	})
+}
+
+// BenchmarkReadSubscribedVariableValues decodes 4 of 300 64 car arrays like telemetry.Data
+func BenchmarkReadSubscribedVariableValues(b *testing.B) {
+	img := buildTestMemoryMap(300, 64)
+
+	sdk, err := Init(memoryMap{bytes.NewReader(img)})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	sdk.Subscribe("CarIdxVar0", "CarIdxVar100", "CarIdxVar200", "CarIdxVar299")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		sdk.tVars.lastVersion = 0
+
+		ok, err := readVariableValues(sdk)
+		if err != nil || !ok {
+			b.Fatal(ok, err)
+		}
+	}
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"embed"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
 
 	"github.com/ianhaycox/ir-standings/connectors/api"
 	"github.com/ianhaycox/ir-standings/connectors/cdn"
@@ -14,6 +16,7 @@ import (
 	cookiejar "github.com/ianhaycox/ir-standings/connectors/jar"
 	"github.com/ianhaycox/ir-standings/irsdk"
 	"github.com/ianhaycox/ir-standings/model/championship/points"
+	"github.com/ianhaycox/ir-standings/predictor"
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
@@ -26,6 +29,7 @@ const (
 	defaultHeight         = 500
 	countBestOf           = 10
 	showTopN              = 10
+	defaultIbtFile        = "/tmp/test.ibt"
 )
 
 //go:embed all:frontend/dist
@@ -66,19 +70,38 @@ func main() {
 
 	var sdk *irsdk.IRSDK
 
-	if runtime.GOOS == "windows" {
+	relay := os.Getenv("IR_STANDINGS_RELAY")
+
+	switch {
+	case relay != "":
+		log.Println("Init irSDK relay from", relay)
+
+		sdk, err = irsdk.Init(irsdk.DialRelay(relay))
+	case runtime.GOOS == "windows":
 		log.Println("Init irSDK Windows")
 
-		sdk = irsdk.Init(nil)
-	} else {
-		reader, err := os.Open("/tmp/test.ibt")
-		if err != nil {
-			log.Fatal(err)
+		sdk, err = irsdk.Init(nil)
+	default:
+		playbackFile := os.Getenv("IR_STANDINGS_IBT")
+		if playbackFile == "" {
+			playbackFile = defaultIbtFile
 		}
 
-		log.Println("Init irSDK Linux(other)")
+		log.Println("Init irSDK Linux(other) playing", playbackFile)
+
+		sdk, err = irsdk.Init(openPlayback(playbackFile))
+	}
+
+	if sdk == nil {
+		log.Fatal(err)
+	}
+
+	if err != nil {
+		log.Println("iRacing telemetry not ready yet:", err)
+	}
 
-		sdk = irsdk.Init(reader)
+	if os.Getenv("IR_STANDINGS_STRICT_SCHEMA") != "" {
+		sdk.StrictSchema(true)
 	}
 
 	defer sdk.Close()
@@ -86,6 +109,17 @@ func main() {
 	// Create an instance of the app structure
 	app := NewApp(sdk, ir, pointsPerSplit, refreshSeconds, countBestOf, int(iracing.KamelSeriesID), showTopN)
 
+	if os.Getenv("IR_STANDINGS_POSITIONS") == "interpolated" {
+		app.positionSource = predictor.InterpolatedPosition
+	}
+
+	switch os.Getenv("IR_STANDINGS_CREDIT") {
+	case "drivers":
+		app.creditRule = predictor.CreditAllDrivers
+	case "entry":
+		app.creditRule = predictor.CreditEntry
+	}
+
 	// Create application with options
 	err = wails.Run(&options.App{
 		Title:  "iRacing Championship Standings",
@@ -107,3 +141,33 @@ func main() {
 		println("Error:", err.Error())
 	}
 }
+
+// playback is a recorded telemetry file presented to the SDK as the memory map
+type playback interface {
+	io.Reader
+	io.ReaderAt
+	io.Closer
+	RealTime(on bool)
+}
+
+// openPlayback of an .ibt or capture file in real-time
+func openPlayback(fileName string) playback {
+	var (
+		p   playback
+		err error
+	)
+
+	if strings.HasSuffix(fileName, irsdk.CaptureExt) {
+		p, err = irsdk.OpenCapture(fileName)
+	} else {
+		p, err = irsdk.OpenIbt(fileName)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p.RealTime(true)
+
+	return p
+}
@@ -7,12 +7,20 @@ import "github.com/ianhaycox/ir-standings/model"
 // {CarClassID: 83, ShortName: "GTO", Name: "Audi 90 GTO", CarsInClass: []results.CarsInClass{{CarID: 76}}},
 
 type PredictedStandings struct {
-	Status         string                        `json:"status"` // iRacing connection status/session, Race, Qualifying,...
-	TrackName      string                        `json:"track_name"`
-	CountBestOf    int                           `json:"count_best_of"`
-	SelfCarClassID int                           `json:"self_car_class_id"`
-	CarClassIDs    []int                         `json:"car_class_ids"`
-	Standings      map[model.CarClassID]Standing `json:"standings"`
+	Status            string                        `json:"status"` // iRacing connection status/session, Race, Qualifying,...
+	TrackName         string                        `json:"track_name"`
+	CountBestOf       int                           `json:"count_best_of"`
+	SelfCarClassID    int                           `json:"self_car_class_id"`
+	CarClassIDs       []int                         `json:"car_class_ids"`
+	IncidentLimit     int                           `json:"incident_limit"`      // 0 if unlimited
+	ResultFromSession bool                          `json:"result_from_session"` // After the checkered flag, iRacing's classification in the session
+	Provisional       bool                          `json:"provisional"`         // Until iRacing marks the session result official
+	SessionState      string                        `json:"session_state"`       // Racing, Checkered, CoolDown etc.
+	Flag              string                        `json:"flag"`                // green, yellow, caution, white, checkered or blank
+	TimeRemaining     float64                       `json:"time_remaining"`      // Seconds, -1 if unlimited
+	LapsRemaining     int                           `json:"laps_remaining"`      // For the leader, -1 if unlimited
+	FinalLap          bool                          `json:"final_lap"`           // The order is about to become final
+	Standings         map[model.CarClassID]Standing `json:"standings"`
 }
 
 type Standing struct {
@@ -24,14 +32,31 @@ type Standing struct {
 }
 
 type PredictedStanding struct {
-	Driving           bool                        `json:"driving"`            // In current session
-	CustID            model.CustID                `json:"cust_id"`            // Key for React
-	DriverName        string                      `json:"driver_name"`        // Driver
-	CarNumber         string                      `json:"car_number"`         // May be blank if not in the session
-	CurrentPosition   model.FinishPositionInClass `json:"current_position"`   // May be first race in the current session
-	PredictedPosition model.FinishPositionInClass `json:"predicted_position"` // Championship position as is
-	CurrentPoints     model.Point                 `json:"current_points"`     // Championship position before race
-	PredictedPoints   model.Point                 `json:"predicted_points"`   // Championship points as is
-	Change            int                         `json:"change"`             // +/- change from current position
-	CarNames          []string                    `json:"car_names"`          // Cars driven in this class
+	Driving            bool                        `json:"driving"`               // In current session
+	CustID             model.CustID                `json:"cust_id"`               // Key for React
+	DriverName         string                      `json:"driver_name"`           // Driver
+	CarNumber          string                      `json:"car_number"`            // May be blank if not in the session
+	CurrentPosition    model.FinishPositionInClass `json:"current_position"`      // May be first race in the current session
+	PredictedPosition  model.FinishPositionInClass `json:"predicted_position"`    // Championship position as is
+	CurrentPoints      model.Point                 `json:"current_points"`        // Championship position before race
+	PredictedPoints    model.Point                 `json:"predicted_points"`      // Championship points as is
+	Change             int                         `json:"change"`                // +/- change from current position
+	CarNames           []string                    `json:"car_names"`             // Cars driven in this class
+	LastLapTime        float32                     `json:"last_lap_time"`         // Seconds, 0 without a lap
+	BestLapTime        float32                     `json:"best_lap_time"`         // Seconds, 0 without a lap
+	AverageLapTime     float32                     `json:"average_lap_time"`      // Of the laps this session
+	GapToClassLeader   float32                     `json:"gap_to_class_leader"`   // Seconds, 0 for the leader
+	IntervalToCarAhead float32                     `json:"interval_to_car_ahead"` // Seconds to the car ahead in class
+	DNF                bool                        `json:"dnf"`                   // Retired from the current race
+	Pitting            bool                        `json:"pitting"`               // Predicted position may be wrong until the next lap
+	Incidents          int                         `json:"incidents"`             // Of the car this race, counted towards the limit
+	IncidentDeltas     []IncidentDelta             `json:"incident_deltas"`       // As they happened this race
+	NearIncidentLimit  bool                        `json:"near_incident_limit"`   // Within a quarter of the disqualification limit
+}
+
+// IncidentDelta e.g. +4x on lap 12
+type IncidentDelta struct {
+	Lap   int `json:"lap"`
+	Count int `json:"count"`
+	Total int `json:"total"`
 }
@@ -15,12 +15,31 @@ import (
 	"github.com/ianhaycox/ir-standings/model/live"
 )
 
+// PositionSource of the race positions in the provisional result
+type PositionSource int
+
+const (
+	ClassPosition        PositionSource = iota // CarIdxClassPosition, updated at the start/finish line
+	InterpolatedPosition                       // laps plus lap distance, updated as cars pass on track
+)
+
+// CreditRule for who scores the result of a car in team events
+type CreditRule int
+
+const (
+	CreditDriver     CreditRule = iota // whoever is driving the car now
+	CreditAllDrivers                   // every driver of the car this race
+	CreditEntry                        // the team, as one entry in the standings
+)
+
 type Predictor struct {
 	previous          *championship.Championship
 	previousStandings map[model.CarClassID]standings.ChampionshipStandings
 	points            points.PointsStructure
 	countBestOf       int
 	carClasses        car.CarClasses
+	positionSource    PositionSource
+	creditRule        CreditRule
 }
 
 func NewPredictor(pointsPerSplit points.PointsPerSplit, countBestOf int, carClasses car.CarClasses) *Predictor {
@@ -32,6 +51,28 @@ func NewPredictor(pointsPerSplit points.PointsPerSplit, countBestOf int, carClas
 	}
 }
 
+// SetPositionSource of the race positions, ClassPosition by default
+func (p *Predictor) SetPositionSource(positionSource PositionSource) {
+	p.positionSource = positionSource
+}
+
+// SetCreditRule for team events, CreditDriver by default
+func (p *Predictor) SetCreditRule(creditRule CreditRule) {
+	p.creditRule = creditRule
+}
+
+// credited with the result of the car. An entry is keyed on the negative TeamID so it can not clash with a CustID.
+func (p *Predictor) credited(car *telemetry.CarInfo) []telemetry.TeamDriver {
+	switch {
+	case p.creditRule == CreditAllDrivers && len(car.Drivers) > 0:
+		return car.Drivers
+	case p.creditRule == CreditEntry && car.TeamID != 0:
+		return []telemetry.TeamDriver{{CustID: -car.TeamID, DriverName: car.TeamName, IRating: car.IRating}}
+	default:
+		return []telemetry.TeamDriver{{CustID: car.CustID, DriverName: car.DriverName, IRating: car.IRating}}
+	}
+}
+
 // Live championship positions
 //
 // {CarClassID: 84, ShortName: "GTP", Name: "Nissan GTP ZX-T", CarsInClass: []results.CarsInClass{{CarID: 77}}},
@@ -43,8 +84,17 @@ func (p *Predictor) Live(pastResults []results.Result, td *telemetry.TelemetryDa
 		CountBestOf:    p.countBestOf,
 		SelfCarClassID: td.SelfCarClassID,
 		CarClassIDs:    p.carClasses.CarClassIDs(),
+		IncidentLimit:  td.IncidentLimit,
+		SessionState:   td.SessionState.String(),
+		Flag:           string(td.Flag),
+		TimeRemaining:  td.TimeRemaining,
+		LapsRemaining:  td.LapsRemaining,
+		FinalLap:       td.FinalLap(),
 	}
 
+	ps.ResultFromSession = useSessionResults(td)
+	ps.Provisional = !ps.ResultFromSession || !td.ResultsOfficial
+
 	seriesID := model.SeriesID(td.SeriesID)
 
 	if p.previous == nil {
@@ -81,7 +131,7 @@ func (p *Predictor) liveStandings(seriesID model.SeriesID, carClassID model.CarC
 		SessionResults: []results.SessionResults{
 			{
 				SimsessionName: "RACE",
-				Results:        p.buildResults(&td.Cars, td.SessionType),
+				Results:        p.raceResults(td),
 			},
 		},
 	})
@@ -138,9 +188,26 @@ func (p *Predictor) provisionalTable(predictedStandings standings.ChampionshipSt
 
 	predictedResult := make([]live.PredictedStanding, 0, len(mergedStandings))
 
-	carNums := make(map[model.CustID]string)
+	driving := make(map[model.CustID]*telemetry.CarInfo)
+
 	for i := range td.Cars {
-		carNums[model.CustID(td.Cars[i].CustID)] = td.Cars[i].CarNumber
+		if !td.Cars[i].IsRacing() {
+			continue
+		}
+
+		for _, credited := range p.credited(&td.Cars[i]) {
+			driving[model.CustID(credited.CustID)] = &td.Cars[i]
+		}
+	}
+
+	reasonOut := make(map[*telemetry.CarInfo]string)
+
+	if useSessionResults(td) {
+		for _, result := range td.Results {
+			if result.CarIdx >= 0 && result.CarIdx < len(td.Cars) {
+				reasonOut[&td.Cars[result.CarIdx]] = result.ReasonOut
+			}
+		}
 	}
 
 	for custID := range mergedStandings {
@@ -149,7 +216,29 @@ func (p *Predictor) provisionalTable(predictedStandings standings.ChampionshipSt
 			ls.Change = int(ls.CurrentPosition - ls.PredictedPosition)
 		}
 
-		ls.CarNumber, ls.Driving = carNums[custID]
+		if car, ok := driving[custID]; ok {
+			ls.Driving = true
+			ls.CarNumber = car.CarNumber
+			ls.DNF = car.Retired
+
+			if reason, ok := reasonOut[car]; ok {
+				ls.DNF = reason != telemetry.ReasonOutRunning
+			}
+
+			ls.Pitting = car.Pitting
+			ls.Incidents = car.TeamIncidents
+			ls.NearIncidentLimit = nearIncidentLimit(car.TeamIncidents, td.IncidentLimit)
+
+			for _, delta := range car.IncidentDeltas {
+				ls.IncidentDeltas = append(ls.IncidentDeltas, live.IncidentDelta{Lap: delta.Lap, Count: delta.Count, Total: delta.Total})
+			}
+
+			ls.LastLapTime = car.LastLapTime
+			ls.BestLapTime = car.BestLapTime
+			ls.AverageLapTime = car.AverageLapTime
+			ls.GapToClassLeader = car.GapToClassLeader
+			ls.IntervalToCarAhead = car.IntervalToCarAhead
+		}
 
 		predictedResult = append(predictedResult, ls)
 	}
@@ -161,33 +250,133 @@ func (p *Predictor) provisionalTable(predictedStandings standings.ChampionshipSt
 	return predictedResult
 }
 
+// nearIncidentLimit when the incidents are within a quarter of the limit, a limit of 0 is unlimited
+func nearIncidentLimit(incidents, limit int) bool {
+	return limit > 0 && incidents*4 >= limit*3
+}
+
+// useSessionResults once the checkered flag has fallen in a race and iRacing has classified the cars
+func useSessionResults(td *telemetry.TelemetryData) bool {
+	return td.SessionType == "RACE" && td.Checkered() && len(td.Results) > 0
+}
+
+// raceResults from the session YAML after the checkered flag, else from the current positions
+func (p *Predictor) raceResults(td *telemetry.TelemetryData) []results.Results {
+	if useSessionResults(td) {
+		return p.sessionResults(td)
+	}
+
+	return p.buildResults(&td.Cars, td.SessionType)
+}
+
+// creditResult of the car to each driver or the entry credited with it
+func (p *Predictor) creditResult(car *telemetry.CarInfo, finishPositionInClass, lapsComplete int) []results.Results {
+	credited := p.credited(car)
+	res := make([]results.Results, 0, len(credited))
+
+	for _, c := range credited {
+		res = append(res, results.Results{
+			CustID:                c.CustID,
+			FinishPositionInClass: finishPositionInClass,
+			LapsComplete:          lapsComplete,
+			CarID:                 car.CarID,
+			CarClassID:            car.CarClassID,
+			DisplayName:           c.DriverName,
+			NewiRating:            c.IRating,
+		})
+	}
+
+	return res
+}
+
+// sessionResults as iRacing classified the cars, cars that did not start are left out as they are from the API result
+func (p *Predictor) sessionResults(td *telemetry.TelemetryData) []results.Results {
+	res := make([]results.Results, 0, len(td.Results))
+
+	for _, result := range td.Results {
+		if result.CarIdx < 0 || result.CarIdx >= len(td.Cars) || !td.Cars[result.CarIdx].IsRacing() {
+			continue
+		}
+
+		res = append(res, p.creditResult(&td.Cars[result.CarIdx], result.ClassPosition, result.LapsComplete)...)
+	}
+
+	return res
+}
+
 // Create a fake result for the race based on current positions
 func (p *Predictor) buildResults(cars *telemetry.CarsInfo, sessionType string) []results.Results {
 	res := make([]results.Results, 0)
 
-	for _, car := range cars {
-		if !car.IsRacing() {
+	positions := p.classification(cars)
+
+	for i := range cars {
+		if !cars[i].IsRacing() {
 			continue
 		}
 
-		racePositionInClass := 0
+		racePositionInClass := unclassifiedPosition
 		lapsComplete := 0
 
 		if sessionType == "RACE" {
-			racePositionInClass = car.RacePositionInClass
-			lapsComplete = car.LapsComplete
+			racePositionInClass = finishPositionInClass(positions[i])
+			lapsComplete = cars[i].LapsComplete
 		}
 
-		res = append(res, results.Results{
-			CustID:                car.CustID,
-			FinishPositionInClass: racePositionInClass,
-			LapsComplete:          lapsComplete,
-			CarID:                 car.CarID,
-			CarClassID:            car.CarClassID,
-			DisplayName:           car.DriverName,
-			NewiRating:            car.IRating,
-		})
+		res = append(res, p.creditResult(&cars[i], racePositionInClass, lapsComplete)...)
 	}
 
 	return res
 }
+
+// unclassifiedPosition is behind every car and outside the points
+const unclassifiedPosition = telemetry.IrMaxCars
+
+// finishPositionInClass of the classification from 1, from 0 like the API and the session results
+func finishPositionInClass(position int) int {
+	if position <= 0 {
+		return unclassifiedPosition
+	}
+
+	return position - 1
+}
+
+// classification of the cars in each class as the official result will be, retired cars are classified behind
+// the running cars by the laps they completed. Unclassified cars are 0.
+func (p *Predictor) classification(cars *telemetry.CarsInfo) [telemetry.IrMaxCars]int {
+	var positions [telemetry.IrMaxCars]int
+
+	byClass := make(map[int][]int)
+
+	for i := range cars {
+		positions[i] = cars[i].RacePositionInClass
+		if p.positionSource == InterpolatedPosition {
+			positions[i] = cars[i].InterpolatedPositionInClass
+		}
+
+		if cars[i].IsRacing() && positions[i] > 0 {
+			byClass[cars[i].CarClassID] = append(byClass[cars[i].CarClassID], i)
+		}
+	}
+
+	for _, carIdxs := range byClass {
+		sort.SliceStable(carIdxs, func(i, j int) bool {
+			a, b := &cars[carIdxs[i]], &cars[carIdxs[j]]
+
+			switch {
+			case a.Retired != b.Retired:
+				return !a.Retired
+			case a.Retired && a.LapsComplete != b.LapsComplete:
+				return a.LapsComplete > b.LapsComplete
+			default:
+				return positions[carIdxs[i]] < positions[carIdxs[j]]
+			}
+		})
+
+		for n, carIdx := range carIdxs {
+			positions[carIdx] = n + 1
+		}
+	}
+
+	return positions
+}
@@ -4,13 +4,19 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/ianhaycox/ir-standings/irsdk"
+	"github.com/ianhaycox/ir-standings/irsdk/iryaml"
 	"github.com/ianhaycox/ir-standings/irsdk/telemetry"
+	"github.com/ianhaycox/ir-standings/model"
 	"github.com/ianhaycox/ir-standings/model/championship/car"
 	"github.com/ianhaycox/ir-standings/model/championship/points"
 	"github.com/ianhaycox/ir-standings/model/data/cars"
 	"github.com/ianhaycox/ir-standings/model/data/results"
+	"github.com/ianhaycox/ir-standings/model/live"
 	"github.com/ianhaycox/ir-standings/test/files"
+	"github.com/ianhaycox/ir-standings/test/memmap"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -95,3 +101,336 @@ func TestPredictorInSeasonNotConnected(t *testing.T) {
 	assert.Equal(t, "Nissan GTP", ps.Standings[84].CarClassName)
 	assert.Len(t, ps.Standings[84].Items, 157)
 }
+
+// predictedPositions by car number
+func predictedPositions(standing live.Standing) map[string]model.FinishPositionInClass {
+	positions := make(map[string]model.FinishPositionInClass)
+
+	for _, item := range standing.Items {
+		positions[item.CarNumber] = item.PredictedPosition
+	}
+
+	return positions
+}
+
+func predictedPoints(standing live.Standing) map[string]model.Point {
+	points := make(map[string]model.Point)
+
+	for _, item := range standing.Items {
+		points[item.CarNumber] = item.PredictedPoints
+	}
+
+	return points
+}
+
+// newTestRace connects telemetry to the race scripted by b, for a predictor of the test points and classes
+func newTestRace(t *testing.T, b *memmap.Builder) (*memmap.Image, *telemetry.Data, *Predictor) {
+	t.Helper()
+
+	img, sdk := b.Connect(t)
+	data := telemetry.NewData(sdk)
+
+	return img, &data, NewPredictor(pointsPerSplit, 10, carClasses)
+}
+
+func TestPredictorLiveFromTelemetry(t *testing.T) {
+	const (
+		gto = 83
+		gtp = 84
+	)
+
+	// car 12 passes car 7 on lap 5
+	race := memmap.NewRace(memmap.RaceSession(285, 1000,
+		iryaml.Driver{CarIdx: 1, UserName: "Seven", UserID: 700, CarNumber: "7", CarClassID: gtp, CarID: 77, IRating: 3000},
+		iryaml.Driver{CarIdx: 2, UserName: "Twelve", UserID: 1200, CarNumber: "12", CarClassID: gtp, CarID: 77, IRating: 2000},
+		iryaml.Driver{CarIdx: 3, UserName: "Three", UserID: 300, CarNumber: "3", CarClassID: gto, CarID: 76, IRating: 1000, TeamIncidentCount: 5},
+	)).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"SessionFlags":        irsdk.FlagGreen,
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2, 3: 1}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{1: 4, 2: 4, 3: 3}),
+			"CarIdxTrackSurface":  memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocOnTrack, 3: irsdk.TrkLocOnTrack}),
+			"CarIdxLastLapTime":   memmap.PerCar(map[int]float32{1: 100, 2: 101, 3: 110}),
+			"CarIdxBestLapTime":   memmap.PerCar(map[int]float32{1: 100, 2: 101, 3: 110}),
+			"CarIdxEstTime":       memmap.PerCar(map[int]float32{1: 30, 2: 29, 3: 10}),
+			"CarIdxOnPitRoad":     memmap.PerCar(map[int]bool{3: true}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 2, 2: 1, 3: 1}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{1: 5, 2: 5, 3: 4}),
+		})
+
+	img, data, p := newTestRace(t, race)
+
+	ps := p.Live([]results.Result{}, data.Telemetry())
+
+	assert.Equal(t, telemetry.Connected, ps.Status)
+	assert.Equal(t, "Twin Ring Motegi Grand Prix", ps.TrackName)
+	assert.Equal(t, "Racing", ps.SessionState)
+	assert.Equal(t, "green", ps.Flag)
+	assert.False(t, ps.FinalLap)
+	assert.Equal(t, model.LapsComplete(4), ps.Standings[gtp].ClassLeaderLapsComplete)
+	assert.Equal(t, 2500, ps.Standings[gtp].SoFByCarClass)
+	assert.Equal(t, map[string]model.FinishPositionInClass{"7": 1, "12": 2}, predictedPositions(ps.Standings[gtp]))
+	assert.Equal(t, map[string]model.FinishPositionInClass{"3": 1}, predictedPositions(ps.Standings[gto]))
+	assert.True(t, ps.Standings[gto].Items[0].Pitting)
+	assert.Equal(t, 5, ps.Standings[gto].Items[0].Incidents)
+	assert.False(t, ps.Standings[gto].Items[0].NearIncidentLimit, "unlimited")
+
+	for _, item := range ps.Standings[gtp].Items {
+		if item.CarNumber == "12" {
+			assert.Equal(t, float32(101), item.LastLapTime)
+			assert.Equal(t, float32(101), item.AverageLapTime)
+			assert.InDelta(t, 1, item.GapToClassLeader, 1e-3)
+			assert.InDelta(t, 1, item.IntervalToCarAhead, 1e-3)
+		}
+	}
+
+	require.True(t, img.Next())
+
+	ps = p.Live([]results.Result{}, data.Telemetry())
+
+	assert.Equal(t, model.LapsComplete(5), ps.Standings[gtp].ClassLeaderLapsComplete)
+	assert.Equal(t, map[string]model.FinishPositionInClass{"7": 2, "12": 1}, predictedPositions(ps.Standings[gtp]))
+}
+
+func TestPredictorInterpolatedPositions(t *testing.T) {
+	const gtp = 84
+
+	// car 12 passes car 7 half way round lap 4, the class positions change at the line
+	race := memmap.NewRace(memmap.RaceSession(285, 1000,
+		iryaml.Driver{CarIdx: 1, UserName: "Seven", UserID: 700, CarNumber: "7", CarClassID: gtp, CarID: 77, IRating: 3000},
+		iryaml.Driver{CarIdx: 2, UserName: "Twelve", UserID: 1200, CarNumber: "12", CarClassID: gtp, CarID: 77, IRating: 2000},
+	)).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{1: 4, 2: 4}),
+			"CarIdxLapDistPct":    memmap.PerCar(map[int]float32{1: 0.50, 2: 0.51}),
+			"CarIdxTrackSurface":  memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocOnTrack}),
+		})
+
+	_, data, p := newTestRace(t, race)
+
+	ps := p.Live([]results.Result{}, data.Telemetry())
+	assert.Equal(t, map[string]model.FinishPositionInClass{"7": 1, "12": 2}, predictedPositions(ps.Standings[gtp]), "class positions")
+
+	p = NewPredictor(pointsPerSplit, 10, carClasses)
+	p.SetPositionSource(InterpolatedPosition)
+	ps = p.Live([]results.Result{}, data.Telemetry())
+	assert.Equal(t, map[string]model.FinishPositionInClass{"7": 2, "12": 1}, predictedPositions(ps.Standings[gtp]), "interpolated")
+}
+
+func TestPredictorRetirements(t *testing.T) {
+	const gtp = 84
+
+	// the leader disconnects on lap 5 and is classified behind the cars still running
+	race := memmap.NewRace(memmap.RaceSession(285, 1000,
+		iryaml.Driver{CarIdx: 1, UserName: "Seven", UserID: 700, CarNumber: "7", CarClassID: gtp, CarID: 77},
+		iryaml.Driver{CarIdx: 2, UserName: "Twelve", UserID: 1200, CarNumber: "12", CarClassID: gtp, CarID: 77},
+		iryaml.Driver{CarIdx: 3, UserName: "Three", UserID: 300, CarNumber: "3", CarClassID: gtp, CarID: 77},
+	)).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2, 3: 3}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{1: 5, 2: 5, 3: 4}),
+			"CarIdxTrackSurface":  memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocOnTrack, 3: irsdk.TrkLocOnTrack}),
+		}).
+		Tick(memmap.Values{
+			"CarIdxLap":          memmap.PerCar(map[int]int{1: -1, 2: 5, 3: 4}),
+			"CarIdxTrackSurface": memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocNotInWorld, 2: irsdk.TrkLocOnTrack, 3: irsdk.TrkLocOnTrack}),
+		})
+
+	img, data, p := newTestRace(t, race)
+
+	ps := p.Live([]results.Result{}, data.Telemetry())
+	assert.Equal(t, map[string]model.FinishPositionInClass{"7": 1, "12": 2, "3": 3}, predictedPositions(ps.Standings[gtp]))
+
+	require.True(t, img.Next())
+
+	ps = p.Live([]results.Result{}, data.Telemetry())
+	assert.Equal(t, map[string]model.FinishPositionInClass{"7": 3, "12": 1, "3": 2}, predictedPositions(ps.Standings[gtp]))
+
+	for _, item := range ps.Standings[gtp].Items {
+		assert.Equal(t, item.CarNumber == "7", item.DNF, item.CarNumber)
+	}
+}
+
+func TestClassification(t *testing.T) {
+	var cars telemetry.CarsInfo
+
+	cars[1] = telemetry.CarInfo{DriverName: "A", CarClassID: 84, RacePositionInClass: 1, LapsComplete: 10, Retired: true}
+	cars[2] = telemetry.CarInfo{DriverName: "B", CarClassID: 84, RacePositionInClass: 2, LapsComplete: 12, Retired: true}
+	cars[3] = telemetry.CarInfo{DriverName: "C", CarClassID: 84, RacePositionInClass: 3, LapsComplete: 9}
+	cars[4] = telemetry.CarInfo{DriverName: "D", CarClassID: 84, RacePositionInClass: 4, LapsComplete: 9}
+	cars[5] = telemetry.CarInfo{DriverName: "E", CarClassID: 83, RacePositionInClass: 1, LapsComplete: 8}
+	cars[6] = telemetry.CarInfo{DriverName: "F", CarClassID: 84}
+
+	positions := NewPredictor(pointsPerSplit, 10, carClasses).classification(&cars)
+
+	assert.Equal(t, []int{4, 3, 1, 2, 1, 0}, positions[1:7], "running, then retired by laps, unclassified stay 0")
+}
+
+func TestFinishPositionInClass(t *testing.T) {
+	assert.Equal(t, 0, finishPositionInClass(1), "winner")
+	assert.Equal(t, 9, finishPositionInClass(10))
+	assert.Equal(t, unclassifiedPosition, finishPositionInClass(0), "outside the points")
+}
+
+func TestNearIncidentLimit(t *testing.T) {
+	tests := []struct {
+		incidents, limit int
+		want             bool
+	}{
+		{incidents: 100, limit: 0, want: false},
+		{incidents: 12, limit: 17, want: false},
+		{incidents: 13, limit: 17, want: true},
+		{incidents: 17, limit: 17, want: true},
+		{incidents: 0, limit: 1, want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, nearIncidentLimit(tt.incidents, tt.limit), "%dx of %d", tt.incidents, tt.limit)
+	}
+}
+
+func TestPredictorSessionResults(t *testing.T) {
+	const gtp = 84
+
+	// car 7 crosses the line first but iRacing classifies car 12 as the winner
+	session := memmap.RaceSession(285, 1000,
+		iryaml.Driver{CarIdx: 1, UserName: "Seven", UserID: 700, CarNumber: "7", CarClassID: gtp, CarID: 77},
+		iryaml.Driver{CarIdx: 2, UserName: "Twelve", UserID: 1200, CarNumber: "12", CarClassID: gtp, CarID: 77},
+	)
+	session.SessionInfo.Sessions[0].ResultsPositions = []iryaml.ResultsPosition{
+		{Position: 1, ClassPosition: 0, CarIdx: 2, LapsComplete: 12, ReasonOutStr: "Running"},
+		{Position: 2, ClassPosition: 1, CarIdx: 1, LapsComplete: 12, ReasonOutStr: "Disqualified"},
+	}
+
+	race := memmap.NewRace(session).
+		Tick(memmap.Values{
+			"SessionState":        irsdk.SessionStateRacing,
+			"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2}),
+			"CarIdxLap":           memmap.PerCar(map[int]int{1: 12, 2: 12}),
+			"CarIdxTrackSurface":  memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocOnTrack}),
+		}).
+		Tick(memmap.Values{
+			"SessionState": irsdk.SessionStateCheckered,
+		})
+
+	img, data, p := newTestRace(t, race)
+
+	ps := p.Live([]results.Result{}, data.Telemetry())
+	assert.False(t, ps.ResultFromSession, "still racing")
+	assert.True(t, ps.Provisional)
+	assert.Equal(t, map[string]model.FinishPositionInClass{"7": 1, "12": 2}, predictedPositions(ps.Standings[gtp]))
+
+	require.True(t, img.Next())
+
+	ps = p.Live([]results.Result{}, data.Telemetry())
+	assert.True(t, ps.ResultFromSession)
+	assert.True(t, ps.Provisional)
+	assert.Equal(t, map[string]model.FinishPositionInClass{"7": 2, "12": 1}, predictedPositions(ps.Standings[gtp]))
+
+	for _, item := range ps.Standings[gtp].Items {
+		assert.Equal(t, item.CarNumber == "7", item.DNF, item.CarNumber)
+	}
+
+	session.SessionInfo.Sessions[0].ResultsOfficial = 1
+	require.NoError(t, img.SetSession(session))
+
+	ps = p.Live([]results.Result{}, data.Telemetry())
+	assert.True(t, ps.ResultFromSession)
+	assert.False(t, ps.Provisional, "official")
+
+	t.Run("Points unchanged by the switch", func(t *testing.T) {
+		session.SessionInfo.Sessions[0].ResultsPositions = []iryaml.ResultsPosition{
+			{Position: 1, ClassPosition: 0, CarIdx: 1, LapsComplete: 12, ReasonOutStr: "Running"},
+			{Position: 2, ClassPosition: 1, CarIdx: 2, LapsComplete: 12, ReasonOutStr: "Running"},
+		}
+
+		race := memmap.NewRace(session).
+			Tick(memmap.Values{
+				"SessionState":        irsdk.SessionStateRacing,
+				"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2}),
+				"CarIdxLap":           memmap.PerCar(map[int]int{1: 12, 2: 12}),
+				"CarIdxTrackSurface":  memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocOnTrack}),
+			}).
+			Tick(memmap.Values{
+				"SessionState": irsdk.SessionStateCheckered,
+			})
+
+		img, data, p := newTestRace(t, race)
+
+		before := p.Live([]results.Result{}, data.Telemetry())
+		require.False(t, before.ResultFromSession)
+
+		require.True(t, img.Next())
+
+		after := p.Live([]results.Result{}, data.Telemetry())
+		require.True(t, after.ResultFromSession)
+
+		assert.Equal(t, map[string]model.Point{"7": 25, "12": 22}, predictedPoints(before.Standings[gtp]), "winner gets the winner's points")
+		assert.Equal(t, predictedPoints(before.Standings[gtp]), predictedPoints(after.Standings[gtp]))
+	})
+}
+
+func TestPredictorCreditRules(t *testing.T) {
+	const gtp = 84
+
+	session := func(driver iryaml.Driver) iryaml.IRSession {
+		session := memmap.RaceSession(285, 1000,
+			driver,
+			iryaml.Driver{CarIdx: 2, UserName: "Carol", UserID: 300, TeamID: 20, TeamName: "Team B", CarNumber: "2", CarClassID: gtp, CarID: 77},
+		)
+		session.WeekendInfo.TeamRacing = 1
+
+		return session
+	}
+
+	alice := iryaml.Driver{CarIdx: 1, UserName: "Alice", UserID: 100, TeamID: 10, TeamName: "Team A", CarNumber: "1", CarClassID: gtp, CarID: 77}
+	bob := alice
+	bob.UserName, bob.UserID = "Bob", 200
+
+	tests := []struct {
+		name string
+		rule CreditRule
+		want map[string]model.Point
+	}{
+		{name: "Driver", rule: CreditDriver, want: map[string]model.Point{"Bob": 25, "Carol": 22}},
+		{name: "All drivers", rule: CreditAllDrivers, want: map[string]model.Point{"Alice": 25, "Bob": 25, "Carol": 22}},
+		{name: "Entry", rule: CreditEntry, want: map[string]model.Point{"Team A": 25, "Team B": 22}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Alice hands the leading car to Bob
+			race := memmap.NewRace(session(alice)).
+				Tick(memmap.Values{
+					"SessionState":        irsdk.SessionStateRacing,
+					"CarIdxClassPosition": memmap.PerCar(map[int]int{1: 1, 2: 2}),
+					"CarIdxLap":           memmap.PerCar(map[int]int{1: 20, 2: 20}),
+					"CarIdxTrackSurface":  memmap.PerCar(map[int]irsdk.TrkLoc{1: irsdk.TrkLocOnTrack, 2: irsdk.TrkLocOnTrack}),
+				})
+
+			img, data, p := newTestRace(t, race)
+			data.Telemetry()
+
+			require.NoError(t, img.SetSession(session(bob)))
+
+			p.SetCreditRule(tt.rule)
+
+			ps := p.Live([]results.Result{}, data.Telemetry())
+
+			got := make(map[string]model.Point)
+
+			for _, item := range ps.Standings[gtp].Items {
+				got[item.DriverName] = item.PredictedPoints
+				assert.True(t, item.Driving, item.DriverName)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
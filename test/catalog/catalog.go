@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+)
+
+// Print the catalog of telemetry variables of live iRacing, an .ibt file, a capture file or a saved JSON catalog,
+// or diff two catalogs and exit 1 when they differ
+func main() {
+	format := flag.String("format", "table", "table, json or markdown")
+	diff := flag.Bool("diff", false, "diff the catalogs of two sources")
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: catalog [-format table|json|markdown] [live|file.ibt|file"+irsdk.CaptureExt+"|file.json]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       catalog -diff old new")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *diff {
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+
+		d := irsdk.DiffCatalogs(readCatalog(flag.Arg(0)), readCatalog(flag.Arg(1)))
+
+		err := d.Write(os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !d.Empty() {
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	catalog := readCatalog(flag.Arg(0))
+
+	var err error
+
+	switch *format {
+	case "table":
+		err = catalog.WriteTable(os.Stdout)
+	case "json":
+		err = catalog.WriteJSON(os.Stdout)
+	case "markdown":
+		err = catalog.WriteMarkdown(os.Stdout)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readCatalog of the source, live iRacing if empty
+func readCatalog(source string) irsdk.Catalog {
+	if filepath.Ext(source) == ".json" {
+		f, err := os.Open(source)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		defer f.Close()
+
+		catalog, err := irsdk.ReadCatalogJSON(f)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return catalog
+	}
+
+	var (
+		sdk *irsdk.IRSDK
+		err error
+	)
+
+	switch {
+	case source == "" || source == "live":
+		sdk, err = irsdk.Init(nil)
+	case filepath.Ext(source) == ".ibt":
+		var ibt *irsdk.IbtReader
+
+		ibt, err = irsdk.OpenIbt(source)
+		if err == nil {
+			sdk, err = irsdk.Init(ibt)
+		}
+	case filepath.Ext(source) == irsdk.CaptureExt:
+		var capture *irsdk.CaptureReader
+
+		capture, err = irsdk.OpenCapture(source)
+		if err == nil {
+			sdk, err = irsdk.Init(capture)
+		}
+	default:
+		log.Fatalf("unknown source %s", source)
+	}
+
+	if err != nil {
+		log.Fatal(source, ": ", err)
+	}
+
+	defer sdk.Close()
+
+	catalog, err := sdk.Catalog()
+	if err != nil {
+		log.Fatal(source, ": ", err)
+	}
+
+	return catalog
+}
@@ -0,0 +1,421 @@
+// Package memmap test utilities to build an iRacing memory map image from Go values, to run the SDK without iRacing
+package memmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-yaml/yaml"
+	"github.com/ianhaycox/ir-standings/irsdk"
+	"github.com/ianhaycox/ir-standings/irsdk/iryaml"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	irsdkVersion   = 2
+	numBuf         = 3 // iRacing rotates through 3 var buffers
+	minSessionSize = 64 * 1024
+	maxCars        = 64
+)
+
+var ErrNoTicks = errors.New("no ticks to build")
+
+// Header of the memory map
+type Header struct {
+	TickRate          int // 60 if 0
+	SessionInfoUpdate int // of the first session
+}
+
+// Var definition of a telemetry variable
+type Var struct {
+	Name  string
+	Type  irsdk.VarType
+	Count int // 1 if 0, 64 for CarIdx arrays
+	Desc  string
+	Unit  string
+}
+
+// Values by variable name for a tick, a scalar or a slice of up to Count elements of any Go type that converts, e.g.
+//
+//	memmap.Values{"SessionState": irsdk.SessionStateRacing, "CarIdxLap": memmap.PerCar(map[int]int{1: 5})}
+type Values map[string]interface{}
+
+// Builder scripts the ticks of a memory map
+type Builder struct {
+	header  Header
+	vars    []Var
+	session iryaml.IRSession
+	ticks   []Values
+}
+
+// NewBuilder of a memory map with the variables
+func NewBuilder(header Header, vars ...Var) *Builder {
+	return &Builder{header: header, vars: append([]Var(nil), vars...)}
+}
+
+// Session YAML of the image
+func (b *Builder) Session(session iryaml.IRSession) *Builder {
+	b.session = session
+
+	return b
+}
+
+// Tick adds the next tick, variables not given keep their value from the previous tick
+func (b *Builder) Tick(values Values) *Builder {
+	next := make(Values)
+
+	if len(b.ticks) > 0 {
+		for name, value := range b.ticks[len(b.ticks)-1] {
+			next[name] = value
+		}
+	}
+
+	for name, value := range values {
+		next[name] = value
+	}
+
+	b.ticks = append(b.ticks, next)
+
+	return b
+}
+
+// Build the image with the first tick published
+func (b *Builder) Build() (*Image, error) {
+	if len(b.ticks) == 0 {
+		return nil, ErrNoTicks
+	}
+
+	tickRate := b.header.TickRate
+	if tickRate == 0 {
+		tickRate = 60
+	}
+
+	img := &Image{offsets: make(map[string]int)}
+
+	varHeaders := make([]byte, len(b.vars)*irsdk.VarHeaderSize)
+
+	for i, v := range b.vars {
+		size := v.Type.Size()
+		if size == 0 {
+			return nil, fmt.Errorf("variable %s has unknown type %d", v.Name, v.Type)
+		}
+
+		if _, ok := img.offsets[v.Name]; ok {
+			return nil, fmt.Errorf("variable %s defined twice", v.Name)
+		}
+
+		if v.Count == 0 {
+			v.Count = 1
+		}
+
+		b.vars[i] = v
+		img.offsets[v.Name] = img.bufLen
+
+		vh := irsdk.VarHeader{Type: v.Type, Offset: img.bufLen, Count: v.Count, Name: v.Name, Desc: v.Desc, Unit: v.Unit}
+		vh.Put(varHeaders[i*irsdk.VarHeaderSize:])
+
+		img.bufLen += size * v.Count
+	}
+
+	for _, values := range b.ticks {
+		row := make([]byte, img.bufLen)
+
+		for name, value := range values {
+			err := b.encode(img, row, name, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		img.rows = append(img.rows, row)
+	}
+
+	session, err := marshalSession(b.session)
+	if err != nil {
+		return nil, err
+	}
+
+	img.sessionOffset = irsdk.MemHeaderSize + len(varHeaders)
+	img.sessionSpace = max(minSessionSize, 2*len(session))
+	img.rowStart = img.sessionOffset + img.sessionSpace
+
+	img.buf = make([]byte, img.rowStart+numBuf*img.bufLen)
+	img.Reader = bytes.NewReader(img.buf)
+
+	img.header = irsdk.MemHeader{
+		Version:           irsdkVersion,
+		Connected:         true,
+		TickRate:          tickRate,
+		SessionInfoUpdate: b.header.SessionInfoUpdate - 1,
+		NumVars:           len(b.vars),
+		VarHeaderOffset:   irsdk.MemHeaderSize,
+		BufLen:            img.bufLen,
+	}
+
+	for i := 0; i < numBuf; i++ {
+		img.header.VarBufs = append(img.header.VarBufs, irsdk.VarBuffer{BufOffset: img.rowStart + i*img.bufLen})
+	}
+
+	copy(img.buf[irsdk.MemHeaderSize:], varHeaders)
+
+	img.firstSession = b.header.SessionInfoUpdate
+	img.putSession(session)
+
+	img.Next()
+
+	return img, nil
+}
+
+func (b *Builder) encode(img *Image, row []byte, name string, value interface{}) error {
+	var v Var
+
+	for i := range b.vars {
+		if b.vars[i].Name == name {
+			v = b.vars[i]
+			break
+		}
+	}
+
+	if v.Name == "" {
+		return fmt.Errorf("value for undefined variable %s", name)
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		if v.Type == irsdk.VarTypeChar && rv.Kind() == reflect.String {
+			rv = reflect.ValueOf([]byte(rv.String()))
+		} else {
+			rv = reflect.ValueOf([]interface{}{value})
+		}
+	}
+
+	if rv.Len() > v.Count {
+		return fmt.Errorf("%d values for %s of %d", rv.Len(), name, v.Count)
+	}
+
+	size := v.Type.Size()
+	offset := img.offsets[name]
+
+	for i := 0; i < rv.Len(); i++ {
+		err := encodeElement(v.Type, row[offset+i*size:], reflect.Indirect(rv.Index(i)))
+		if err != nil {
+			return fmt.Errorf("%s[%d], err:%w", name, i, err)
+		}
+	}
+
+	return nil
+}
+
+func encodeElement(varType irsdk.VarType, dst []byte, ev reflect.Value) error {
+	if ev.Kind() == reflect.Interface {
+		ev = ev.Elem()
+	}
+
+	switch {
+	case varType == irsdk.VarTypeChar && ev.Kind() == reflect.Uint8:
+		dst[0] = byte(ev.Uint())
+	case varType == irsdk.VarTypeBool && ev.Kind() == reflect.Bool:
+		if ev.Bool() {
+			dst[0] = 1
+		}
+	case (varType == irsdk.VarTypeInt || varType == irsdk.VarTypeBitField) && ev.CanInt():
+		binary.LittleEndian.PutUint32(dst, uint32(ev.Int()))
+	case (varType == irsdk.VarTypeInt || varType == irsdk.VarTypeBitField) && ev.CanUint():
+		binary.LittleEndian.PutUint32(dst, uint32(ev.Uint()))
+	case varType == irsdk.VarTypeFloat && ev.CanFloat():
+		binary.LittleEndian.PutUint32(dst, math.Float32bits(float32(ev.Float())))
+	case varType == irsdk.VarTypeDouble && ev.CanFloat():
+		binary.LittleEndian.PutUint64(dst, math.Float64bits(ev.Float()))
+	default:
+		return fmt.Errorf("can not encode %s as %s", ev.Type(), varType)
+	}
+
+	return nil
+}
+
+// PerCar values for a CarIdx array, cars not given are zero
+func PerCar[T any](values map[int]T) []T {
+	result := make([]T, maxCars)
+
+	for carIdx, value := range values {
+		result[carIdx] = value
+	}
+
+	return result
+}
+
+// Image of the memory map, irsdk.Init accepts it as the shared memory
+type Image struct {
+	*bytes.Reader
+
+	buf           []byte
+	header        irsdk.MemHeader
+	firstSession  int // sessionInfoUpdate of the first session
+	offsets       map[string]int
+	bufLen        int
+	sessionOffset int
+	sessionSpace  int
+	rowStart      int
+	rows          [][]byte
+	tick          int
+}
+
+func (img *Image) Close() error { return nil }
+
+// Next publishes the next tick in the next var buffer like iRacing, false when there are no more ticks
+func (img *Image) Next() bool {
+	if img.tick >= len(img.rows) {
+		return false
+	}
+
+	buf := img.tick % numBuf
+
+	copy(img.buf[img.rowStart+buf*img.bufLen:], img.rows[img.tick])
+
+	img.tick++
+
+	img.header.VarBufs[buf].TickCount = img.tick
+	img.header.Put(img.buf)
+
+	return true
+}
+
+// Tick last published, from 1
+func (img *Image) Tick() int {
+	return img.tick
+}
+
+// SetSession replaces the session YAML and increments sessionInfoUpdate
+func (img *Image) SetSession(session iryaml.IRSession) error {
+	yml, err := img.marshalSession(session)
+	if err != nil {
+		return err
+	}
+
+	img.putSession(yml)
+
+	return nil
+}
+
+// Restart after Disconnect like iRacing starting again, the ticks are published from the first again and the
+// session counts from the first sessionInfoUpdate
+func (img *Image) Restart(session iryaml.IRSession) error {
+	yml, err := img.marshalSession(session)
+	if err != nil {
+		return err
+	}
+
+	img.tick = 0
+	img.header.Connected = true
+	img.header.SessionInfoUpdate = img.firstSession - 1
+
+	for i := range img.header.VarBufs {
+		img.header.VarBufs[i].TickCount = 0
+	}
+
+	img.putSession(yml)
+	img.Next()
+
+	return nil
+}
+
+// Disconnect clears the connected status like iRacing exiting
+func (img *Image) Disconnect() {
+	img.header.Connected = false
+	img.header.Put(img.buf)
+}
+
+func (img *Image) putSession(yml []byte) {
+	clear(img.buf[img.sessionOffset : img.sessionOffset+img.sessionSpace])
+	copy(img.buf[img.sessionOffset:], yml)
+
+	img.header.SessionInfoUpdate++
+	img.header.SessionInfoLen = len(yml)
+	img.header.SessionInfoOffset = img.sessionOffset
+	img.header.Put(img.buf)
+}
+
+// marshalSession that fits in the space of the image
+func (img *Image) marshalSession(session iryaml.IRSession) ([]byte, error) {
+	yml, err := marshalSession(session)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(yml) > img.sessionSpace {
+		return nil, fmt.Errorf("session of %d bytes does not fit in %d", len(yml), img.sessionSpace)
+	}
+
+	return yml, nil
+}
+
+// marshalSession like iRacing as a YAML document
+func marshalSession(session iryaml.IRSession) ([]byte, error) {
+	yml, err := yaml.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("can not marshal session, err:%w", err)
+	}
+
+	return append(append([]byte("---\n"), yml...), "...\n"...), nil
+}
+
+// RaceVars are the variables telemetry.Data reads
+var RaceVars = []Var{
+	{Name: "SessionNum", Type: irsdk.VarTypeInt},
+	{Name: "SessionState", Type: irsdk.VarTypeInt},
+	{Name: "SessionFlags", Type: irsdk.VarTypeBitField},
+	{Name: "SessionTimeRemain", Type: irsdk.VarTypeDouble, Unit: "s"},
+	{Name: "SessionLapsRemainEx", Type: irsdk.VarTypeInt},
+	{Name: "CarIdxClassPosition", Type: irsdk.VarTypeInt, Count: maxCars},
+	{Name: "CarIdxLap", Type: irsdk.VarTypeInt, Count: maxCars},
+	{Name: "CarIdxTrackSurface", Type: irsdk.VarTypeInt, Count: maxCars},
+	{Name: "CarIdxLapDistPct", Type: irsdk.VarTypeFloat, Count: maxCars, Unit: "%"},
+	{Name: "CarIdxLastLapTime", Type: irsdk.VarTypeFloat, Count: maxCars, Unit: "s"},
+	{Name: "CarIdxBestLapTime", Type: irsdk.VarTypeFloat, Count: maxCars, Unit: "s"},
+	{Name: "CarIdxEstTime", Type: irsdk.VarTypeFloat, Count: maxCars, Unit: "s"},
+	{Name: "CarIdxOnPitRoad", Type: irsdk.VarTypeBool, Count: maxCars},
+	{Name: "SessionTime", Type: irsdk.VarTypeDouble, Unit: "s"},
+}
+
+// NewRace builder of the RaceVars with the session, e.g. a RaceSession
+func NewRace(session iryaml.IRSession) *Builder {
+	return NewBuilder(Header{SessionInfoUpdate: 1}, RaceVars...).Session(session)
+}
+
+// Connect an SDK to the built image, failing the test if either can not be made
+func (b *Builder) Connect(t testing.TB) (*Image, *irsdk.IRSDK) {
+	t.Helper()
+
+	img, err := b.Build()
+	require.NoError(t, err)
+
+	sdk, err := irsdk.Init(img)
+	require.NoError(t, err)
+
+	return img, sdk
+}
+
+// RaceSession with a single RACE session and the drivers, the first is the pace car
+func RaceSession(seriesID, subsessionID int, drivers ...iryaml.Driver) iryaml.IRSession {
+	session := iryaml.IRSession{
+		WeekendInfo: iryaml.WeekendInfo{
+			SeriesID:         seriesID,
+			SubSessionID:     subsessionID,
+			TrackID:          195,
+			TrackDisplayName: "Twin Ring Motegi",
+			TrackConfigName:  "Grand Prix",
+		},
+		SessionInfo: iryaml.SessionInfo{
+			Sessions: []iryaml.Session{{SessionNum: 0, SessionType: "Race", SessionName: "RACE"}},
+		},
+	}
+
+	session.DriverInfo.Drivers = append([]iryaml.Driver{{CarIdx: 0, UserName: "Pace Car", CarIsPaceCar: 1}}, drivers...)
+
+	return session
+}
@@ -0,0 +1,138 @@
+package memmap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+	"github.com/ianhaycox/ir-standings/irsdk/iryaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImage(t *testing.T) {
+	session := iryaml.IRSession{WeekendInfo: iryaml.WeekendInfo{TrackID: 195, TrackName: "motegi fullcourse"}}
+
+	img, err := NewBuilder(Header{SessionInfoUpdate: 1},
+		Var{Name: "SessionFlags", Type: irsdk.VarTypeBitField},
+		Var{Name: "Speed", Type: irsdk.VarTypeFloat, Unit: "m/s"},
+		Var{Name: "SessionTime", Type: irsdk.VarTypeDouble},
+		Var{Name: "OnPitRoad", Type: irsdk.VarTypeBool},
+		Var{Name: "CarIdxLap", Type: irsdk.VarTypeInt, Count: 64},
+	).
+		Session(session).
+		Tick(Values{"SessionFlags": irsdk.FlagGreen, "Speed": 10.5, "SessionTime": 1.5, "CarIdxLap": PerCar(map[int]int{1: 3, 2: -1})}).
+		Tick(Values{"Speed": float32(20.5), "OnPitRoad": true}).
+		Tick(Values{"CarIdxLap": []int{0, 4}}).
+		Tick(Values{}).
+		Build()
+	require.NoError(t, err)
+
+	sdk, err := irsdk.Init(img)
+	require.NoError(t, err)
+
+	assert.Equal(t, 195, sdk.GetSession().WeekendInfo.TrackID)
+	assert.Equal(t, 1, sdk.GetSessionUpdate())
+
+	flags, err := irsdk.Value[irsdk.SessionFlags](sdk, "SessionFlags")
+	assert.NoError(t, err)
+	assert.Equal(t, irsdk.FlagGreen, flags)
+
+	speed, err := irsdk.Value[float32](sdk, "Speed")
+	assert.NoError(t, err)
+	assert.Equal(t, float32(10.5), speed)
+
+	laps, err := irsdk.Values[int](sdk, "CarIdxLap")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 3, -1}, laps[:3])
+
+	t.Run("Next tick, values carry over", func(t *testing.T) {
+		assert.True(t, img.Next())
+		assert.Equal(t, 2, img.Tick())
+
+		ok, err := sdk.WaitForData(time.Millisecond)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		speed, err := irsdk.Value[float32](sdk, "Speed")
+		assert.NoError(t, err)
+		assert.Equal(t, float32(20.5), speed)
+
+		onPitRoad, err := irsdk.Value[bool](sdk, "OnPitRoad")
+		assert.NoError(t, err)
+		assert.True(t, onPitRoad)
+
+		sessionTime, err := irsdk.Value[float64](sdk, "SessionTime")
+		assert.NoError(t, err)
+		assert.Equal(t, 1.5, sessionTime)
+	})
+
+	t.Run("Rotates through the var buffers", func(t *testing.T) {
+		for img.Next() {
+			ok, err := sdk.WaitForData(time.Millisecond)
+			require.NoError(t, err)
+			assert.True(t, ok)
+		}
+
+		assert.Equal(t, 4, sdk.GetLastVersion())
+
+		laps, err := irsdk.Values[int](sdk, "CarIdxLap")
+		assert.NoError(t, err)
+		assert.Equal(t, []int{0, 4, 0}, laps[:3])
+	})
+
+	t.Run("Session update", func(t *testing.T) {
+		session.WeekendInfo.TrackID = 196
+		require.NoError(t, img.SetSession(session))
+
+		_, err := sdk.WaitForData(time.Millisecond)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, sdk.GetSessionUpdate())
+		assert.Equal(t, 196, sdk.GetSession().WeekendInfo.TrackID)
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		img.Disconnect()
+
+		ok, err := sdk.WaitForData(time.Millisecond)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.False(t, sdk.IsConnected())
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		session.WeekendInfo.TrackID = 197
+		require.NoError(t, img.Restart(session))
+		assert.Equal(t, 1, img.Tick())
+
+		ok, err := sdk.WaitForData(time.Millisecond)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		assert.Equal(t, 1, sdk.GetSessionUpdate(), "counts from the first again")
+		assert.Equal(t, 197, sdk.GetSession().WeekendInfo.TrackID)
+
+		speed, err := irsdk.Value[float32](sdk, "Speed")
+		assert.NoError(t, err)
+		assert.Equal(t, float32(10.5), speed)
+	})
+}
+
+func TestBuildErrors(t *testing.T) {
+	_, err := NewBuilder(Header{}, Var{Name: "Speed", Type: irsdk.VarTypeFloat}).Build()
+	assert.ErrorIs(t, err, ErrNoTicks)
+
+	_, err = NewBuilder(Header{}, Var{Name: "Speed", Type: irsdk.VarTypeFloat}).Tick(Values{"Unknown": 1}).Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder(Header{}, Var{Name: "Speed", Type: irsdk.VarTypeFloat}).Tick(Values{"Speed": "fast"}).Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder(Header{}, Var{Name: "Speed", Type: irsdk.VarTypeFloat}).Tick(Values{"Speed": []float32{1, 2}}).Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder(Header{}, Var{Name: "Speed", Type: irsdk.VarTypeFloat}, Var{Name: "Speed", Type: irsdk.VarTypeInt}).
+		Tick(Values{}).Build()
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+)
+
+const waitForData = 100 * time.Millisecond
+
+// Record the live iRacing telemetry to a capture file for replay on Linux, or an .ibt file for analysis tools, stop with Ctrl-C
+func main() {
+	out := flag.String("o", "session"+irsdk.CaptureExt, "capture file, or .ibt file")
+	every := flag.Int("every", 1, "record every Nth tick to a capture file, 60 is once a second")
+
+	flag.Parse()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer f.Close()
+
+	sdk, err := irsdk.Init(nil)
+	if sdk == nil {
+		log.Fatal(err)
+	}
+
+	defer sdk.Close()
+
+	var (
+		write    func() (bool, error)
+		closeOut func() error
+	)
+
+	if filepath.Ext(*out) == ".ibt" {
+		iw := irsdk.NewIbtWriter(sdk, f)
+		write, closeOut = iw.WriteSample, iw.Close
+	} else {
+		rec := irsdk.NewRecorder(sdk, f, *every)
+		write, closeOut = rec.Capture, rec.Close
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+
+	rows, err := record(sdk, write, stop)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = closeOut()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Recorded", rows, "rows to", *out)
+}
+
+// record until stopped, returning the rows written. The writer sees every poll, including while iRacing is
+// disconnected, so that it notices a restart.
+func record(sdk *irsdk.IRSDK, write func() (bool, error), stop <-chan os.Signal) (int, error) {
+	rows := 0
+
+	for {
+		select {
+		case <-stop:
+			return rows, nil
+		default:
+		}
+
+		_, err := sdk.WaitForData(waitForData)
+		if err != nil {
+			log.Println(err)
+		}
+
+		written, err := write()
+		if err != nil {
+			return rows, err
+		}
+
+		if written {
+			rows++
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+	"github.com/ianhaycox/ir-standings/test/memmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordIracingRestart(t *testing.T) {
+	img, sdk := memmap.NewRace(memmap.RaceSession(285, 1000)).
+		Tick(memmap.Values{"SessionState": irsdk.SessionStateRacing}).
+		Tick(memmap.Values{}).
+		Connect(t)
+
+	var buf bytes.Buffer
+
+	rec := irsdk.NewRecorder(sdk, &buf, 1)
+	stop := make(chan os.Signal, 1)
+
+	// what iRacing does after each write, it restarts with the same sessionInfoUpdate counter but a new session
+	script := []func(){
+		func() { img.Next() },
+		img.Disconnect,
+		func() { require.NoError(t, img.Restart(memmap.RaceSession(285, 1001))) },
+		func() { img.Next() },
+		func() { stop <- os.Interrupt },
+	}
+
+	write := func() (bool, error) {
+		written, err := rec.Capture()
+
+		if len(script) > 0 {
+			script[0]()
+			script = script[1:]
+		}
+
+		return written, err
+	}
+
+	// a loop that stops writing while disconnected never gets to the end of the script
+	deadline := time.AfterFunc(time.Second, func() {
+		select {
+		case stop <- os.Interrupt:
+		default:
+		}
+	})
+	defer deadline.Stop()
+
+	rows, err := record(sdk, write, stop)
+	require.NoError(t, err)
+	require.NoError(t, rec.Close())
+
+	assert.Equal(t, 4, rows)
+
+	cr, err := irsdk.NewCaptureReader(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 4, cr.NumSamples())
+
+	player, err := irsdk.Init(cr)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, player.GetSession().WeekendInfo.SubSessionID)
+
+	require.NoError(t, cr.Seek(2))
+
+	_, err = player.WaitForData(time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 1001, player.GetSession().WeekendInfo.SubSessionID, "session written again after the restart")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/ianhaycox/ir-standings/irsdk"
+)
+
+// Relay the live iRacing telemetry to overlays on other machines started with IR_STANDINGS_RELAY=host:port, stop with Ctrl-C
+func main() {
+	const (
+		waitForData = 100 * time.Millisecond
+	)
+
+	listen := flag.String("listen", ":32032", "address to accept overlays on")
+
+	flag.Parse()
+
+	sdk, err := irsdk.Init(nil)
+	if sdk == nil {
+		log.Fatal(err)
+	}
+
+	defer sdk.Close()
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rs := irsdk.NewRelayServer(sdk, ln)
+
+	go func() {
+		err := rs.Serve()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	log.Println("Relaying telemetry on", ln.Addr())
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+
+	for {
+		select {
+		case <-stop:
+			err = rs.Close()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			return
+		default:
+		}
+
+		_, err := sdk.WaitForData(waitForData)
+		if err != nil {
+			log.Println(err)
+		}
+
+		// also publishes iRacing disconnecting
+		_, err = rs.Publish()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
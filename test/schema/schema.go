@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ianhaycox/ir-standings/irsdk/iryaml"
+)
+
+// Report the session YAML keys missing from the iryaml structs, and the struct fields never populated,
+// of a session saved with ExportSessionTo. Exits 1 when they have drifted.
+func main() {
+	sections := flag.String("sections", "", "comma separated top level sections to check, e.g. DriverInfo,WeekendInfo, all if empty")
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: schema [-sections DriverInfo,WeekendInfo] session.yaml")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	yml, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := iryaml.CheckSchema(string(yml))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *sections != "" {
+		report = report.Filter(strings.Split(*sections, ",")...)
+	}
+
+	for _, path := range report.Unknown {
+		fmt.Println("unknown", path)
+	}
+
+	for _, path := range report.Missing {
+		fmt.Println("missing", path)
+	}
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}